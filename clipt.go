@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/muesli/termenv"
@@ -17,6 +18,8 @@ func Render(providers []schema.ChatProvider, options ...Option) {
 		Cmds:      tui.DefaultCmds,
 		Providers: providers,
 		Style:     style.Default(style.Dark),
+
+		TrashRetention: 30 * 24 * time.Hour,
 		Debug: struct {
 			Log  bool
 			Path string
@@ -26,6 +29,8 @@ func Render(providers []schema.ChatProvider, options ...Option) {
 		},
 	}
 
+	config.History.Size = 1000
+
 	for _, opt := range options {
 		opt(&config)
 	}
@@ -42,10 +47,14 @@ func Render(providers []schema.ChatProvider, options ...Option) {
 	os.Setenv("COLORTERM", "truecolor")
 	termenv.ColorProfile()
 
+	layout := tui.NewLayout(config)
+	defer layout.Close()
+
 	app := tea.NewProgram(
-		tui.NewLayout(config),
+		layout,
 		tea.WithAltScreen(),
 		tea.WithMouseCellMotion(),
+		tea.WithOutput(layout.Output),
 	)
 
 	if _, err := app.Run(); err != nil {
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/struki84/clipt"
 	"github.com/struki84/clipt/providers"
 	"github.com/struki84/clipt/storage"
@@ -26,6 +29,16 @@ func main() {
 
 	sqlite := *storage.NewSQLite(dbPath)
 
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		err := clipt.Import(sqlite, os.Args[2:]...)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	for _, llm := range llms {
 		models = append(models, providers.NewOpenRouter(llm, sqlite))
 	}
@@ -33,7 +46,9 @@ func main() {
 	clipt.Render(
 		models,
 		clipt.WithStorage(sqlite),
+		clipt.WithAutoTitle(providers.NewOpenRouter("google/gemini-3-flash-preview", sqlite)),
 		clipt.WithDebugLog("debug.log"),
+		clipt.WithHistory(".clipt_history", 1000),
 		clipt.WithStyle(style.Default(style.CatppuccinMocha)),
 	)
 }
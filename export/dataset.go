@@ -0,0 +1,97 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// Example is a rated prompt/response pair, written as one JSONL line in the
+// chat format used by most fine-tuning and evaluation tools.
+type Example struct {
+	Messages []ExampleMessage `json:"messages"`
+	Rating   string           `json:"rating"`
+	Note     string           `json:"note,omitempty"`
+	Metadata ExampleMetadata  `json:"metadata"`
+}
+
+type ExampleMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type ExampleMetadata struct {
+	SessionID string `json:"session_id"`
+	MsgID     string `json:"msg_id"`
+	Provider  string `json:"provider,omitempty"`
+	Model     string `json:"model,omitempty"`
+}
+
+// Examples returns the rated answers of the sessions, each paired with the
+// prompt that preceded it.
+func Examples(sessions []schema.ChatSession) []Example {
+	examples := []Example{}
+
+	for _, session := range sessions {
+		prompt := ""
+		for _, msg := range session.Msgs {
+			switch msg.Role {
+			case schema.UserMsg:
+				prompt = msg.Content
+			case schema.AIMsg:
+				rating := msg.Meta[schema.MetaRating]
+				if rating == "" || prompt == "" {
+					continue
+				}
+
+				examples = append(examples, Example{
+					Messages: []ExampleMessage{
+						{Role: "user", Content: prompt},
+						{Role: "assistant", Content: msg.Content},
+					},
+					Rating: rating,
+					Note:   msg.Meta[schema.MetaRatingNote],
+					Metadata: ExampleMetadata{
+						SessionID: session.ID,
+						MsgID:     msg.ID,
+						Provider:  msg.Meta[schema.MetaProvider],
+						Model:     msg.Meta[schema.MetaModel],
+					},
+				})
+			}
+		}
+	}
+
+	return examples
+}
+
+// WriteDataset writes the rated answers of the sessions as JSONL and
+// returns the number of examples written.
+func WriteDataset(w io.Writer, sessions []schema.ChatSession) (int, error) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	examples := Examples(sessions)
+	for _, example := range examples {
+		err := encoder.Encode(example)
+		if err != nil {
+			return 0, fmt.Errorf("Error writing dataset: %v", err)
+		}
+	}
+
+	return len(examples), nil
+}
+
+// DatasetToFile writes the rated answers of the sessions to a JSONL file.
+func DatasetToFile(sessions []schema.ChatSession, path string) (int, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("Error writing dataset: %v", err)
+	}
+	defer file.Close()
+
+	return WriteDataset(file, sessions)
+}
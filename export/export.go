@@ -0,0 +1,301 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/yuin/goldmark"
+)
+
+type Format int
+
+const (
+	Markdown Format = iota
+	JSON
+	HTML
+)
+
+func (f Format) String() string {
+	switch f {
+	case Markdown:
+		return "markdown"
+	case JSON:
+		return "json"
+	case HTML:
+		return "html"
+	default:
+		return fmt.Sprintf("Format(%d)", f)
+	}
+}
+
+// Ext returns the file extension used for the format.
+func (f Format) Ext() string {
+	switch f {
+	case JSON:
+		return ".json"
+	case HTML:
+		return ".html"
+	default:
+		return ".md"
+	}
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch strings.ToLower(strings.TrimPrefix(s, ".")) {
+	case "md", "markdown":
+		return Markdown, nil
+	case "json":
+		return JSON, nil
+	case "html", "htm":
+		return HTML, nil
+	default:
+		return 0, fmt.Errorf("Unknown export format: %s", s)
+	}
+}
+
+// Version of the JSON export document, checked by the importer.
+const Version = 1
+
+// Document is the structure of the JSON export.
+type Document struct {
+	Format    string    `json:"format"`
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Title     string    `json:"title"`
+	Provider  string    `json:"provider,omitempty"`
+	Model     string    `json:"model,omitempty"`
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Msgs      []Message `json:"messages"`
+}
+
+type Message struct {
+	ID        string            `json:"id,omitempty"`
+	Role      string            `json:"role"`
+	Content   string            `json:"content"`
+	Timestamp time.Time         `json:"timestamp"`
+	Meta      map[string]string `json:"meta,omitempty"`
+}
+
+// Render renders the session in the given format. The style is only used
+// for the HTML page colors.
+func Render(session schema.ChatSession, format Format, style schema.LayoutStyle) ([]byte, error) {
+	switch format {
+	case Markdown:
+		return []byte(ToMarkdown(session)), nil
+	case JSON:
+		return ToJSON(session)
+	case HTML:
+		page, err := ToHTML(session, style)
+		return []byte(page), err
+	default:
+		return nil, fmt.Errorf("Unknown export format: %s", format)
+	}
+}
+
+// ToFile renders the session and writes it to path.
+func ToFile(session schema.ChatSession, format Format, style schema.LayoutStyle, path string) error {
+	data, err := Render(session, format, style)
+	if err != nil {
+		return err
+	}
+
+	err = os.WriteFile(path, data, 0o644)
+	if err != nil {
+		return fmt.Errorf("Error writing export: %v", err)
+	}
+
+	return nil
+}
+
+// ToClipboard renders the session and copies it to the system clipboard.
+func ToClipboard(session schema.ChatSession, format Format, style schema.LayoutStyle) error {
+	data, err := Render(session, format, style)
+	if err != nil {
+		return err
+	}
+
+	err = clipboard.WriteAll(string(data))
+	if err != nil {
+		return fmt.Errorf("Error copying export to clipboard: %v", err)
+	}
+
+	return nil
+}
+
+// FileName returns a file name for the session export derived from its title.
+func FileName(session schema.ChatSession, format Format) string {
+	name := strings.Trim(unsafeChars.ReplaceAllString(strings.ToLower(session.Title), "-"), "-")
+	if name == "" {
+		name = session.ID
+	}
+
+	return name + format.Ext()
+}
+
+var unsafeChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func ToMarkdown(session schema.ChatSession) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# %s\n\n", session.Title)
+	fmt.Fprintf(&b, "_%s_\n", time.Unix(session.CreatedAt, 0).Format("2 Jan 2006 15:04"))
+
+	for _, msg := range exported(session.Msgs) {
+		fmt.Fprintf(&b, "\n## %s\n", roleHeader(msg.Role))
+		fmt.Fprintf(&b, "_%s_\n\n", time.Unix(msg.Timestamp, 0).Format("2 Jan 2006 15:04"))
+		b.WriteString(strings.TrimSpace(msg.Content))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func ToJSON(session schema.ChatSession) ([]byte, error) {
+	doc := Document{
+		Format:    "clipt",
+		Version:   Version,
+		ID:        session.ID,
+		ParentID:  session.ParentID,
+		Title:     session.Title,
+		Provider:  session.Provider,
+		Model:     session.Model,
+		Tags:      session.Tags,
+		CreatedAt: time.Unix(session.CreatedAt, 0).UTC(),
+		Msgs:      []Message{},
+	}
+
+	for _, msg := range exported(session.Msgs) {
+		doc.Msgs = append(doc.Msgs, Message{
+			ID:        msg.ID,
+			Role:      msg.Role.String(),
+			Content:   msg.Content,
+			Timestamp: time.Unix(msg.Timestamp, 0).UTC(),
+			Meta:      msg.Meta,
+		})
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("Error encoding export: %v", err)
+	}
+
+	return data, nil
+}
+
+// ToHTML renders the session as a self-contained HTML page, with markdown
+// converted to HTML and colors taken from the layout style.
+func ToHTML(session schema.ChatSession, style schema.LayoutStyle) (string, error) {
+	var b strings.Builder
+
+	colors := map[string]string{
+		"bg":       colorOf(style.Chat.ContentView.GetBackground(), style.WhitespaceBGcolor),
+		"fg":       colorOf(style.Chat.Header.GetForeground(), "#CDD6F4"),
+		"muted":    colorOf(style.InfoLine.GetForeground(), "#7F849C"),
+		"userBG":   colorOf(style.Chat.Msg.User.GetBackground(), "#181825"),
+		"userBar":  colorOf(style.Chat.Msg.User.GetBorderLeftForeground(), "#B4BEFE"),
+		"sysBG":    colorOf(style.Chat.Msg.Sys.GetBackground(), "#181825"),
+		"sysBar":   colorOf(style.Chat.Msg.Sys.GetBorderLeftForeground(), "#181825"),
+		"errBar":   colorOf(style.Chat.Msg.Err.GetBorderLeftForeground(), "#E64553"),
+		"codeBG":   colorOf(style.Menu.ContentView.GetBackground(), "#11111B"),
+		"headerBG": colorOf(style.Chat.Header.GetBorderLeftForeground(), "#11111B"),
+	}
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(session.Title))
+	b.WriteString("<style>\n")
+	fmt.Fprintf(&b, "body { background: %s; color: %s; font-family: -apple-system, 'Segoe UI', sans-serif; max-width: 860px; margin: 0 auto; padding: 24px; }\n", colors["bg"], colors["fg"])
+	fmt.Fprintf(&b, "header { border-left: 4px solid %s; border-right: 4px solid %s; padding: 4px 12px; margin-bottom: 24px; }\n", colors["headerBG"], colors["headerBG"])
+	fmt.Fprintf(&b, ".date { color: %s; font-size: 0.85em; }\n", colors["muted"])
+	b.WriteString(".msg { margin: 16px 0; padding: 12px 16px; }\n")
+	fmt.Fprintf(&b, ".user { background: %s; border-left: 4px solid %s; border-right: 4px solid %s; white-space: pre-wrap; }\n", colors["userBG"], colors["userBar"], colors["userBar"])
+	fmt.Fprintf(&b, ".sys { background: %s; border-left: 4px solid %s; white-space: pre-wrap; }\n", colors["sysBG"], colors["sysBar"])
+	fmt.Fprintf(&b, ".err { background: %s; border-left: 4px solid %s; white-space: pre-wrap; }\n", colors["sysBG"], colors["errBar"])
+	fmt.Fprintf(&b, "pre, code { background: %s; }\n", colors["codeBG"])
+	b.WriteString("pre { padding: 12px; overflow-x: auto; }\n")
+	fmt.Fprintf(&b, "a { color: %s; }\n", colors["userBar"])
+	b.WriteString("</style>\n</head>\n<body>\n")
+
+	fmt.Fprintf(&b, "<header><h1>%s</h1><div class=\"date\">%s</div></header>\n",
+		html.EscapeString(session.Title),
+		time.Unix(session.CreatedAt, 0).Format("2 Jan 2006 15:04"),
+	)
+
+	for _, msg := range exported(session.Msgs) {
+		date := time.Unix(msg.Timestamp, 0).Format("2 Jan 2006 15:04")
+
+		switch msg.Role {
+		case schema.AIMsg:
+			var content bytes.Buffer
+			err := goldmark.Convert([]byte(msg.Content), &content)
+			if err != nil {
+				return "", fmt.Errorf("Error rendering message: %v", err)
+			}
+
+			fmt.Fprintf(&b, "<div class=\"msg ai\">%s<div class=\"date\">%s</div></div>\n", content.String(), date)
+		default:
+			class := map[schema.MsgRole]string{schema.UserMsg: "user", schema.SysMsg: "sys", schema.ErrMsg: "err"}[msg.Role]
+			fmt.Fprintf(&b, "<div class=\"msg %s\">%s\n<div class=\"date\">%s (%s)</div></div>\n",
+				class,
+				html.EscapeString(msg.Content),
+				roleHeader(msg.Role),
+				date,
+			)
+		}
+	}
+
+	b.WriteString("</body>\n</html>\n")
+
+	return b.String(), nil
+}
+
+// exported filters out internal messages which are only shown in the tui.
+func exported(msgs []schema.Msg) []schema.Msg {
+	result := []schema.Msg{}
+	for _, msg := range msgs {
+		if msg.Role == schema.InternalMsg {
+			continue
+		}
+
+		result = append(result, msg)
+	}
+
+	return result
+}
+
+func roleHeader(role schema.MsgRole) string {
+	switch role {
+	case schema.UserMsg:
+		return "User"
+	case schema.AIMsg:
+		return "Assistant"
+	case schema.SysMsg:
+		return "System"
+	case schema.ErrMsg:
+		return "Error"
+	default:
+		return role.String()
+	}
+}
+
+func colorOf(color lipgloss.TerminalColor, fallback string) string {
+	if c, ok := color.(lipgloss.Color); ok && strings.HasPrefix(string(c), "#") {
+		return string(c)
+	}
+
+	if fallback == "" {
+		return "#1E1E2E"
+	}
+
+	return fallback
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/struki84/clipt/tui/style"
+)
+
+func testSession() schema.ChatSession {
+	return schema.ChatSession{
+		ID:        "abc12345",
+		Title:     "Parser <bugs>",
+		CreatedAt: 1700000000,
+		Msgs: []schema.Msg{
+			{Role: schema.UserMsg, Content: "Why does it <panic>?", Timestamp: 1700000010},
+			{Role: schema.InternalMsg, Content: "Switched model", Timestamp: 1700000015},
+			{Role: schema.AIMsg, Content: "Because of **nil**.", Timestamp: 1700000020},
+		},
+	}
+}
+
+func TestToMarkdown(t *testing.T) {
+	md := ToMarkdown(testSession())
+
+	if !strings.HasPrefix(md, "# Parser <bugs>\n") {
+		t.Errorf("Expected title header, got %q", md)
+	}
+	if !strings.Contains(md, "## User") || !strings.Contains(md, "## Assistant") {
+		t.Errorf("Expected role headers, got %q", md)
+	}
+	if strings.Contains(md, "Switched model") {
+		t.Error("Expected internal messages to be left out")
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	data, err := ToJSON(testSession())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	doc := Document{}
+	err = json.Unmarshal(data, &doc)
+	if err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if doc.Format != "clipt" || doc.Version != Version || doc.ID != "abc12345" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	if len(doc.Msgs) != 2 || doc.Msgs[1].Role != "AIMsg" {
+		t.Errorf("Expected 2 messages ending with AIMsg, got %+v", doc.Msgs)
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	page, err := ToHTML(testSession(), style.Default(style.CatppuccinMocha))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !strings.Contains(page, "&lt;panic&gt;") {
+		t.Error("Expected user content to be escaped")
+	}
+	if !strings.Contains(page, "<strong>nil</strong>") {
+		t.Error("Expected AI markdown to be rendered")
+	}
+	if !strings.Contains(page, "#1E1E2E") {
+		t.Error("Expected colors from the layout style")
+	}
+}
+
+func TestFileName(t *testing.T) {
+	name := FileName(testSession(), HTML)
+	if name != "parser-bugs.html" {
+		t.Errorf("Expected parser-bugs.html, got %s", name)
+	}
+}
+
+func TestWriteDataset(t *testing.T) {
+	rated := testSession()
+	rated.Msgs[2].ID = "msg1"
+	rated.Msgs[2].Meta = schema.MsgMeta{
+		schema.MetaRating:     schema.RatingBad,
+		schema.MetaRatingNote: "too short",
+		schema.MetaModel:      "test-model",
+	}
+
+	var b strings.Builder
+	count, err := WriteDataset(&b, []schema.ChatSession{rated, testSession()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("Expected 1 rated example, got %d", count)
+	}
+
+	example := Example{}
+	err = json.Unmarshal([]byte(b.String()), &example)
+	if err != nil {
+		t.Fatalf("Expected a JSON line, got %v", err)
+	}
+
+	if len(example.Messages) != 2 || example.Messages[0].Content != "Why does it <panic>?" || example.Messages[1].Role != "assistant" {
+		t.Errorf("Expected the prompt/response pair, got %+v", example.Messages)
+	}
+	if example.Rating != schema.RatingBad || example.Note != "too short" || example.Metadata.Model != "test-model" {
+		t.Errorf("Expected rating, note and model, got %+v", example)
+	}
+}
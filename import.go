@@ -0,0 +1,37 @@
+package clipt
+
+import (
+	"fmt"
+
+	"github.com/struki84/clipt/importer"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// Import imports ChatGPT, Anthropic or clipt JSON exports into the storage
+// and prints a summary for each file. It backs the `clipt import <file>...`
+// entry point of a clipt binary.
+func Import(storage schema.SessionStorage, paths ...string) error {
+	if storage == nil {
+		return fmt.Errorf("Import requires a session storage")
+	}
+
+	if len(paths) == 0 {
+		return fmt.Errorf("Usage: clipt import <file>...")
+	}
+
+	// The passphrase prompt is part of the chat, importing runs before it
+	if lockable, ok := storage.(schema.LockableStorage); ok && lockable.Locked() {
+		return fmt.Errorf("The storage is encrypted and locked, unlock it before importing, e.g. with Unlock or UnlockWithKey")
+	}
+
+	for _, path := range paths {
+		result, err := importer.ImportFile(storage, path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+
+		fmt.Printf("%s: imported %d %s sessions, skipped %d already imported\n", path, result.Imported, result.Source, result.Skipped)
+	}
+
+	return nil
+}
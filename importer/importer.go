@@ -0,0 +1,397 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/struki84/clipt/export"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+type Source int
+
+const (
+	Clipt Source = iota
+	ChatGPT
+	Anthropic
+)
+
+func (s Source) String() string {
+	switch s {
+	case Clipt:
+		return "clipt"
+	case ChatGPT:
+		return "chatgpt"
+	case Anthropic:
+		return "anthropic"
+	default:
+		return fmt.Sprintf("Source(%d)", s)
+	}
+}
+
+// Result summarizes an import run.
+type Result struct {
+	Source   Source
+	Imported int
+	Skipped  int
+}
+
+// ImportFile reads an export file and imports its conversations into the
+// storage, see Import.
+func ImportFile(storage schema.SessionStorage, path string) (Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, fmt.Errorf("Error reading import file: %v", err)
+	}
+
+	return Import(storage, data)
+}
+
+// Import converts the conversations in data and saves them as sessions.
+// Session IDs are derived from the IDs in the source export, so sessions
+// that were already imported are skipped instead of duplicated, including
+// the ones moved to the trash since.
+func Import(storage schema.SessionStorage, data []byte) (Result, error) {
+	source, err := Detect(data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	sessions, err := Parse(source, data)
+	if err != nil {
+		return Result{}, err
+	}
+
+	trashed := map[string]bool{}
+	if trash, ok := storage.(schema.TrashStorage); ok {
+		for _, session := range trash.ListTrash() {
+			trashed[session.ID] = true
+		}
+	}
+
+	result := Result{Source: source}
+	for _, session := range sessions {
+		existing, err := storage.LoadSession(session.ID)
+		if trashed[session.ID] || (err == nil && existing.ID == session.ID) {
+			result.Skipped++
+			continue
+		}
+
+		_, err = storage.SaveSession(session)
+		if err != nil {
+			return result, fmt.Errorf("Error importing session %q: %v", session.Title, err)
+		}
+
+		result.Imported++
+	}
+
+	return result, nil
+}
+
+// Detect guesses the source of an export from its structure.
+func Detect(data []byte) (Source, error) {
+	var probe map[string]json.RawMessage
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		items := []map[string]json.RawMessage{}
+		err := json.Unmarshal(data, &items)
+		if err != nil {
+			return 0, fmt.Errorf("Error decoding import file: %v", err)
+		}
+
+		if len(items) == 0 {
+			return 0, fmt.Errorf("Import file contains no conversations")
+		}
+
+		probe = items[0]
+	} else {
+		err := json.Unmarshal(data, &probe)
+		if err != nil {
+			return 0, fmt.Errorf("Error decoding import file: %v", err)
+		}
+	}
+
+	if _, ok := probe["mapping"]; ok {
+		return ChatGPT, nil
+	}
+
+	if _, ok := probe["chat_messages"]; ok {
+		return Anthropic, nil
+	}
+
+	if format, ok := probe["format"]; ok && string(format) == `"clipt"` {
+		return Clipt, nil
+	}
+
+	return 0, fmt.Errorf("Unknown import format")
+}
+
+// Parse converts an export of the given source to chat sessions.
+func Parse(source Source, data []byte) ([]schema.ChatSession, error) {
+	switch source {
+	case Clipt:
+		return parseClipt(data)
+	case ChatGPT:
+		return parseChatGPT(data)
+	case Anthropic:
+		return parseAnthropic(data)
+	default:
+		return nil, fmt.Errorf("Unknown import source: %s", source)
+	}
+}
+
+func parseClipt(data []byte) ([]schema.ChatSession, error) {
+	docs := []export.Document{}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		err := json.Unmarshal(data, &docs)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding clipt export: %v", err)
+		}
+	} else {
+		doc := export.Document{}
+		err := json.Unmarshal(data, &doc)
+		if err != nil {
+			return nil, fmt.Errorf("Error decoding clipt export: %v", err)
+		}
+
+		docs = append(docs, doc)
+	}
+
+	sessions := []schema.ChatSession{}
+	for _, doc := range docs {
+		if doc.Version > export.Version {
+			return nil, fmt.Errorf("Clipt export version %d is newer than supported version %d", doc.Version, export.Version)
+		}
+
+		session := schema.ChatSession{
+			ID:        doc.ID,
+			ParentID:  doc.ParentID,
+			Title:     doc.Title,
+			Provider:  doc.Provider,
+			Model:     doc.Model,
+			Tags:      doc.Tags,
+			CreatedAt: doc.CreatedAt.Unix(),
+			Msgs:      []schema.Msg{},
+		}
+
+		for _, msg := range doc.Msgs {
+			session.Msgs = append(session.Msgs, schema.Msg{
+				ID:        msg.ID,
+				Role:      schema.EnumRole(msg.Role),
+				Content:   msg.Content,
+				Timestamp: msg.Timestamp.Unix(),
+				Meta:      msg.Meta,
+			})
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+type chatGPTConversation struct {
+	ID             string                 `json:"id"`
+	ConversationID string                 `json:"conversation_id"`
+	Title          string                 `json:"title"`
+	CreateTime     float64                `json:"create_time"`
+	CurrentNode    string                 `json:"current_node"`
+	Mapping        map[string]chatGPTNode `json:"mapping"`
+}
+
+type chatGPTNode struct {
+	ID      string          `json:"id"`
+	Parent  string          `json:"parent"`
+	Message *chatGPTMessage `json:"message"`
+}
+
+type chatGPTMessage struct {
+	Author struct {
+		Role string `json:"role"`
+	} `json:"author"`
+	CreateTime float64 `json:"create_time"`
+	Content    struct {
+		ContentType string            `json:"content_type"`
+		Parts       []json.RawMessage `json:"parts"`
+	} `json:"content"`
+}
+
+func parseChatGPT(data []byte) ([]schema.ChatSession, error) {
+	conversations := []chatGPTConversation{}
+	err := json.Unmarshal(data, &conversations)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding ChatGPT export: %v", err)
+	}
+
+	sessions := []schema.ChatSession{}
+	for _, conv := range conversations {
+		convID := conv.ConversationID
+		if convID == "" {
+			convID = conv.ID
+		}
+
+		title := conv.Title
+		if title == "" {
+			title = fmt.Sprintf("Session - %s", importID(ChatGPT, convID))
+		}
+
+		session := schema.ChatSession{
+			ID:        importID(ChatGPT, convID),
+			Title:     title,
+			CreatedAt: int64(conv.CreateTime),
+			Msgs:      []schema.Msg{},
+		}
+
+		// The mapping is a tree of edits and regenerations, follow the
+		// branch that ends at the current node to get the visible thread
+		path := []chatGPTNode{}
+		visited := map[string]bool{}
+		for id := conv.CurrentNode; id != "" && !visited[id]; {
+			node, ok := conv.Mapping[id]
+			if !ok {
+				break
+			}
+
+			visited[id] = true
+			path = append(path, node)
+			id = node.Parent
+		}
+
+		for i := len(path) - 1; i >= 0; i-- {
+			msg := path[i].Message
+			if msg == nil {
+				continue
+			}
+
+			role, ok := map[string]schema.MsgRole{
+				"user":      schema.UserMsg,
+				"assistant": schema.AIMsg,
+				"system":    schema.SysMsg,
+			}[msg.Author.Role]
+			if !ok {
+				continue
+			}
+
+			parts := []string{}
+			for _, raw := range msg.Content.Parts {
+				var part string
+				if json.Unmarshal(raw, &part) == nil && part != "" {
+					parts = append(parts, part)
+				}
+			}
+
+			content := strings.Join(parts, "\n")
+			if strings.TrimSpace(content) == "" {
+				continue
+			}
+
+			timestamp := int64(msg.CreateTime)
+			if timestamp == 0 {
+				timestamp = session.CreatedAt
+			}
+
+			session.Msgs = append(session.Msgs, schema.Msg{
+				Role:      role,
+				Content:   content,
+				Timestamp: timestamp,
+			})
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+type anthropicConversation struct {
+	UUID         string             `json:"uuid"`
+	Name         string             `json:"name"`
+	CreatedAt    time.Time          `json:"created_at"`
+	ChatMessages []anthropicMessage `json:"chat_messages"`
+}
+
+type anthropicMessage struct {
+	Text      string    `json:"text"`
+	Sender    string    `json:"sender"`
+	CreatedAt time.Time `json:"created_at"`
+	Content   []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+func parseAnthropic(data []byte) ([]schema.ChatSession, error) {
+	conversations := []anthropicConversation{}
+	err := json.Unmarshal(data, &conversations)
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding Anthropic export: %v", err)
+	}
+
+	sessions := []schema.ChatSession{}
+	for _, conv := range conversations {
+		title := conv.Name
+		if title == "" {
+			title = fmt.Sprintf("Session - %s", importID(Anthropic, conv.UUID))
+		}
+
+		session := schema.ChatSession{
+			ID:        importID(Anthropic, conv.UUID),
+			Title:     title,
+			CreatedAt: conv.CreatedAt.Unix(),
+			Msgs:      []schema.Msg{},
+		}
+
+		msgs := conv.ChatMessages
+		sort.SliceStable(msgs, func(i, j int) bool {
+			return msgs[i].CreatedAt.Before(msgs[j].CreatedAt)
+		})
+
+		for _, msg := range msgs {
+			role := schema.UserMsg
+			if msg.Sender == "assistant" {
+				role = schema.AIMsg
+			}
+
+			content := msg.Text
+			if content == "" {
+				parts := []string{}
+				for _, part := range msg.Content {
+					if part.Type == "text" && part.Text != "" {
+						parts = append(parts, part.Text)
+					}
+				}
+
+				content = strings.Join(parts, "\n")
+			}
+
+			if strings.TrimSpace(content) == "" {
+				continue
+			}
+
+			session.Msgs = append(session.Msgs, schema.Msg{
+				Role:      role,
+				Content:   content,
+				Timestamp: msg.CreatedAt.Unix(),
+			})
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
+// importID derives a stable session ID from the conversation ID of the
+// source, so importing the same export twice yields the same sessions.
+func importID(source Source, id string) string {
+	sum := sha256.Sum256([]byte(source.String() + ":" + id))
+	return hex.EncodeToString(sum[:])[:12]
+}
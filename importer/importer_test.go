@@ -0,0 +1,167 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/struki84/clipt/storage"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+const chatGPTExport = `[{
+	"title": "Go generics",
+	"create_time": 1700000000.5,
+	"conversation_id": "c-1",
+	"current_node": "n3",
+	"mapping": {
+		"root": {"id": "root", "parent": null, "message": null},
+		"n1": {"id": "n1", "parent": "root", "message": {"author": {"role": "user"}, "create_time": 1700000001, "content": {"content_type": "text", "parts": ["What are generics?"]}}},
+		"n2": {"id": "n2", "parent": "n1", "message": {"author": {"role": "assistant"}, "create_time": 1700000002, "content": {"content_type": "text", "parts": ["Type parameters."]}}},
+		"n2b": {"id": "n2b", "parent": "n1", "message": {"author": {"role": "assistant"}, "create_time": 1700000003, "content": {"content_type": "text", "parts": ["Discarded branch."]}}},
+		"n3": {"id": "n3", "parent": "n2", "message": {"author": {"role": "tool"}, "create_time": 1700000004, "content": {"content_type": "text", "parts": ["ignored"]}}}
+	}
+}]`
+
+const anthropicExport = `[{
+	"uuid": "a-1",
+	"name": "Rust lifetimes",
+	"created_at": "2024-05-01T10:00:00Z",
+	"chat_messages": [
+		{"sender": "assistant", "created_at": "2024-05-01T10:00:05Z", "text": "", "content": [{"type": "text", "text": "They track borrows."}]},
+		{"sender": "human", "created_at": "2024-05-01T10:00:01Z", "text": "Explain lifetimes"}
+	]
+}]`
+
+func TestDetect(t *testing.T) {
+	cases := map[string]Source{
+		chatGPTExport:                         ChatGPT,
+		anthropicExport:                       Anthropic,
+		`{"format": "clipt", "version": 1}`:   Clipt,
+		`[{"format": "clipt", "version": 1}]`: Clipt,
+	}
+
+	for data, expected := range cases {
+		source, err := Detect([]byte(data))
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if source != expected {
+			t.Errorf("Expected %s, got %s", expected, source)
+		}
+	}
+
+	_, err := Detect([]byte(`{"foo": "bar"}`))
+	if err == nil {
+		t.Error("Expected error for unknown format")
+	}
+}
+
+func TestParseChatGPT(t *testing.T) {
+	sessions, err := Parse(ChatGPT, []byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 session, got %d", len(sessions))
+	}
+
+	session := sessions[0]
+	if session.Title != "Go generics" || session.CreatedAt != 1700000000 {
+		t.Errorf("Unexpected session header: %+v", session)
+	}
+	if len(session.Msgs) != 2 {
+		t.Fatalf("Expected 2 messages on the current branch, got %d", len(session.Msgs))
+	}
+	if session.Msgs[0].Role != schema.UserMsg || session.Msgs[1].Content != "Type parameters." {
+		t.Errorf("Unexpected messages: %+v", session.Msgs)
+	}
+}
+
+func TestParseAnthropic(t *testing.T) {
+	sessions, err := Parse(Anthropic, []byte(anthropicExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	msgs := sessions[0].Msgs
+	if len(msgs) != 2 {
+		t.Fatalf("Expected 2 messages, got %d", len(msgs))
+	}
+	if msgs[0].Role != schema.UserMsg || msgs[1].Content != "They track borrows." {
+		t.Errorf("Expected messages ordered by time, got %+v", msgs)
+	}
+}
+
+func TestImportDeduplicates(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "importer_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqlite := storage.NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqlite == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	result, err := Import(*sqlite, []byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 0 {
+		t.Errorf("Expected 1 imported, got %+v", result)
+	}
+
+	result, err = Import(*sqlite, []byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("Expected 1 skipped on re-import, got %+v", result)
+	}
+
+	sessions := sqlite.ListSessions()
+	if len(sessions) != 1 {
+		t.Fatalf("Expected 1 stored session, got %d", len(sessions))
+	}
+	if sessions[0].CreatedAt != 1700000000 {
+		t.Errorf("Expected original creation time, got %d", sessions[0].CreatedAt)
+	}
+}
+
+func TestImportSkipsTrash(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "importer_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqlite := storage.NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqlite == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	_, err = Import(*sqlite, []byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	sessionID := sqlite.ListSessions()[0].ID
+	sqlite.DeleteSession(sessionID)
+
+	result, err := Import(*sqlite, []byte(chatGPTExport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("Expected the trashed session to be skipped, got %+v", result)
+	}
+
+	sqlite.RestoreSession(sessionID)
+
+	sessions := sqlite.ListSessions()
+	if len(sessions) != 1 || len(sqlite.ListTrash()) != 0 {
+		t.Fatalf("Expected 1 stored session after restoring, got %d", len(sessions))
+	}
+}
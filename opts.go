@@ -1,6 +1,8 @@
 package clipt
 
 import (
+	"time"
+
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/struki84/clipt/tui/schema"
 )
@@ -37,6 +39,80 @@ func WithStyle(style schema.LayoutStyle) Option {
 	}
 }
 
+// WithAutoTitle titles new sessions after their first exchange using the
+// given titler, usually a small and cheap model.
+func WithAutoTitle(titler schema.SessionTitler) Option {
+	return func(conf *schema.Config) {
+		conf.AutoTitle = titler
+	}
+}
+
+// WithTrashRetention sets how long deleted sessions stay in the trash
+// before being purged, zero keeps them until purged manually.
+func WithTrashRetention(retention time.Duration) Option {
+	return func(conf *schema.Config) {
+		conf.TrashRetention = retention
+	}
+}
+
+// WithRetention enforces the retention policy on the storage on startup
+// and periodically while clipt is running.
+func WithRetention(policy schema.RetentionPolicy) Option {
+	return func(conf *schema.Config) {
+		conf.Retention = policy
+	}
+}
+
+// WithTemplates loads prompt templates for the /templates menu from the
+// directory, every file is a template and {{name}} marks a variable.
+func WithTemplates(dir string) Option {
+	return func(conf *schema.Config) {
+		conf.TemplateDir = dir
+	}
+}
+
+// WithInputKeys binds sending the prompt and inserting a newline to the
+// keys, e.g. []string{"ctrl+s"} and []string{"enter"}. Terminals don't
+// report shift+enter, map it to alt+enter or ctrl+j in the terminal to use
+// it.
+func WithInputKeys(send []string, newline []string) Option {
+	return func(conf *schema.Config) {
+		conf.Input.SendKeys = send
+		conf.Input.NewlineKeys = newline
+	}
+}
+
+// WithInputHeight sets how many rows the input grows to before scrolling.
+func WithInputHeight(rows int) Option {
+	return func(conf *schema.Config) {
+		conf.Input.MaxHeight = rows
+	}
+}
+
+// WithEditorSend sends prompts written in $EDITOR (ctrl+e) once the editor
+// is closed, instead of loading them into the input.
+func WithEditorSend() Option {
+	return func(conf *schema.Config) {
+		conf.Input.EditorSend = true
+	}
+}
+
+// WithHistory saves the history of sent prompts to the file, keeping the
+// most recent size prompts.
+func WithHistory(path string, size int) Option {
+	return func(conf *schema.Config) {
+		conf.History.Path = path
+		conf.History.Size = size
+	}
+}
+
+// WithSidebar starts with the sessions sidebar open.
+func WithSidebar() Option {
+	return func(conf *schema.Config) {
+		conf.Sidebar = true
+	}
+}
+
 func WithDebugLog(path string) Option {
 	return func(conf *schema.Config) {
 		conf.Debug.Log = true
@@ -13,6 +13,8 @@ import (
 )
 
 type Anthropic struct {
+	debugHooks
+
 	LLM           *anthropic.LLM
 	streamHandler func(ctx context.Context, chunk []byte) error
 	currentModel  string
@@ -41,6 +43,10 @@ func (model *Anthropic) Name() string {
 	return model.currentModel
 }
 
+func (model *Anthropic) Model() string {
+	return model.currentModel
+}
+
 func (model *Anthropic) Description() string {
 	desc := fmt.Sprintf("%s by Anthropic", model.currentModel)
 	return desc
@@ -48,6 +54,8 @@ func (model *Anthropic) Description() string {
 
 func (model *Anthropic) Stream(ctx context.Context, callback func(ctx context.Context, msg schema.Msg) error) {
 	model.streamHandler = func(ctx context.Context, chunk []byte) error {
+		model.emit(model.currentModel, schema.DebugChunk, string(chunk))
+
 		callback(ctx, schema.Msg{
 			Stream:    true,
 			Role:      schema.AIMsg,
@@ -59,6 +67,10 @@ func (model *Anthropic) Stream(ctx context.Context, callback func(ctx context.Co
 	}
 }
 
+func (model *Anthropic) GenerateTitle(ctx context.Context, session schema.ChatSession) (string, error) {
+	return generateTitle(ctx, model.LLM, session)
+}
+
 func (model *Anthropic) Run(ctx context.Context, input string, session schema.ChatSession) error {
 	buffer, err := model.storage.LoadMsgs(session.ID)
 	if err != nil {
@@ -84,8 +96,12 @@ func (model *Anthropic) Run(ctx context.Context, input string, session schema.Ch
 		llms.TextParts(llms.ChatMessageTypeHuman, input),
 	}
 
+	model.emitRequest(model.currentModel, content)
+
+	start := time.Now()
 	response, err := model.LLM.GenerateContent(ctx, content, llms.WithStreamingFunc(model.streamHandler))
 	if err != nil {
+		model.emit(model.currentModel, schema.DebugError, err.Error())
 		fmt.Println(err)
 		return err
 	}
@@ -94,8 +110,11 @@ func (model *Anthropic) Run(ctx context.Context, input string, session schema.Ch
 		Role:      schema.AIMsg,
 		Content:   response.Choices[0].Content,
 		Timestamp: time.Now().Unix(),
+		Meta:      responseMeta("anthropic", model.currentModel, time.Since(start), response.Choices[0]),
 	}
 
+	model.emitResponse(model.currentModel, aiMsg.Meta)
+
 	err = model.storage.SaveMsg(session.ID, aiMsg)
 	if err != nil {
 		log.Println(err)
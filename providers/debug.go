@@ -0,0 +1,55 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// debugHooks reports raw provider events to the debug panel, it's embedded
+// in the providers to implement schema.DebugProvider.
+type debugHooks struct {
+	debugHandler func(event schema.DebugEvent)
+}
+
+func (hooks *debugHooks) Debug(callback func(event schema.DebugEvent)) {
+	hooks.debugHandler = callback
+}
+
+func (hooks *debugHooks) emit(provider string, kind string, text string) {
+	if hooks.debugHandler == nil {
+		return
+	}
+
+	hooks.debugHandler(schema.DebugEvent{
+		Time:     time.Now(),
+		Provider: provider,
+		Kind:     kind,
+		Text:     text,
+	})
+}
+
+func (hooks *debugHooks) emitRequest(provider string, content []llms.MessageContent) {
+	if hooks.debugHandler == nil {
+		return
+	}
+
+	payload, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		payload = []byte(fmt.Sprintf("%+v", content))
+	}
+
+	hooks.emit(provider, schema.DebugRequest, string(payload))
+}
+
+func (hooks *debugHooks) emitResponse(provider string, meta schema.MsgMeta) {
+	if hooks.debugHandler == nil {
+		return
+	}
+
+	payload, _ := json.Marshal(meta)
+	hooks.emit(provider, schema.DebugResponse, string(payload))
+}
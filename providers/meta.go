@@ -0,0 +1,40 @@
+package providers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+// responseMeta collects the metadata recorded with a generated message.
+func responseMeta(provider string, model string, latency time.Duration, choice *llms.ContentChoice) schema.MsgMeta {
+	meta := schema.MsgMeta{
+		schema.MetaProvider: provider,
+		schema.MetaModel:    model,
+		schema.MetaLatency:  latency.Round(time.Millisecond).String(),
+	}
+
+	if choice.StopReason != "" {
+		meta[schema.MetaFinishReason] = choice.StopReason
+	}
+
+	// Token counts are reported under different keys by each backend
+	tokens := map[string][]string{
+		schema.MetaPromptTokens:     {"PromptTokens", "InputTokens"},
+		schema.MetaCompletionTokens: {"CompletionTokens", "OutputTokens"},
+		schema.MetaTotalTokens:      {"TotalTokens"},
+	}
+
+	for key, names := range tokens {
+		for _, name := range names {
+			if value, ok := choice.GenerationInfo[name]; ok {
+				meta[key] = fmt.Sprint(value)
+				break
+			}
+		}
+	}
+
+	return meta
+}
@@ -14,6 +14,8 @@ import (
 )
 
 type OpenRouter struct {
+	debugHooks
+
 	LLM           *openai.LLM
 	streamHandler func(ctx context.Context, chunk []byte) error
 	currentModel  string
@@ -48,6 +50,10 @@ func (model *OpenRouter) Name() string {
 	return model.currentModel
 }
 
+func (model *OpenRouter) Model() string {
+	return model.currentModel
+}
+
 func (model *OpenRouter) Description() string {
 	desc := fmt.Sprintf("%s by OpenAI", model.currentModel)
 	return desc
@@ -55,6 +61,8 @@ func (model *OpenRouter) Description() string {
 
 func (model *OpenRouter) Stream(ctx context.Context, callback func(ctx context.Context, msg schema.Msg) error) {
 	model.streamHandler = func(ctx context.Context, chunk []byte) error {
+		model.emit(model.currentModel, schema.DebugChunk, string(chunk))
+
 		callback(ctx, schema.Msg{
 			Stream:    true,
 			Role:      schema.AIMsg,
@@ -66,6 +74,10 @@ func (model *OpenRouter) Stream(ctx context.Context, callback func(ctx context.C
 	}
 }
 
+func (model *OpenRouter) GenerateTitle(ctx context.Context, session schema.ChatSession) (string, error) {
+	return generateTitle(ctx, model.LLM, session)
+}
+
 func (model *OpenRouter) Run(ctx context.Context, input string, session schema.ChatSession) error {
 	buffer, err := model.storage.LoadMsgs(session.ID)
 	if err != nil {
@@ -91,8 +103,12 @@ func (model *OpenRouter) Run(ctx context.Context, input string, session schema.C
 		llms.TextParts(llms.ChatMessageTypeHuman, input),
 	}
 
+	model.emitRequest(model.currentModel, content)
+
+	start := time.Now()
 	response, err := model.LLM.GenerateContent(ctx, content, llms.WithStreamingFunc(model.streamHandler))
 	if err != nil {
+		model.emit(model.currentModel, schema.DebugError, err.Error())
 		fmt.Println(err)
 		return err
 	}
@@ -101,8 +117,11 @@ func (model *OpenRouter) Run(ctx context.Context, input string, session schema.C
 		Role:      schema.AIMsg,
 		Content:   response.Choices[0].Content,
 		Timestamp: time.Now().Unix(),
+		Meta:      responseMeta("openrouter", model.currentModel, time.Since(start), response.Choices[0]),
 	}
 
+	model.emitResponse(model.currentModel, aiMsg.Meta)
+
 	err = model.storage.SaveMsg(session.ID, aiMsg)
 	if err != nil {
 		log.Println(err)
@@ -0,0 +1,45 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/tmc/langchaingo/llms"
+)
+
+const titlePrompt = "Write a short title (max 6 words) for the following conversation. Reply with the title only, no quotes or punctuation at the end."
+
+func generateTitle(ctx context.Context, llm llms.Model, session schema.ChatSession) (string, error) {
+	history := []string{}
+	for _, msg := range session.Msgs {
+		if msg.Role != schema.UserMsg && msg.Role != schema.AIMsg {
+			continue
+		}
+
+		history = append(history, fmt.Sprintf("%s: %s", msg.Role, msg.Content))
+	}
+
+	content := []llms.MessageContent{
+		llms.TextParts(llms.ChatMessageTypeSystem, titlePrompt),
+		llms.TextParts(llms.ChatMessageTypeHuman, strings.Join(history, "\n")),
+	}
+
+	response, err := llm.GenerateContent(ctx, content, llms.WithMaxTokens(32))
+	if err != nil {
+		return "", err
+	}
+
+	if len(response.Choices) == 0 {
+		return "", fmt.Errorf("Empty response while generating title")
+	}
+
+	title := strings.TrimSpace(response.Choices[0].Content)
+	title = strings.Trim(title, "\"'`#*. ")
+	if title == "" {
+		return "", fmt.Errorf("Empty title generated")
+	}
+
+	return title, nil
+}
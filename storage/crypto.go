@@ -0,0 +1,393 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// encPrefix marks message content encrypted with AES-GCM
+	encPrefix = "enc:v1:"
+
+	kdfIterations = 600_000
+	keySize       = 32
+
+	saltSetting     = "encryption.salt"
+	kdfSetting      = "encryption.kdf"
+	verifierSetting = "encryption.verifier"
+	verifierText    = "clipt"
+)
+
+var ErrLocked = errors.New("Storage is encrypted and locked, unlock it with the passphrase first")
+
+var ErrWrongKey = errors.New("Wrong passphrase or key")
+
+// Setting is a key value pair stored in the settings table.
+type Setting struct {
+	Key   string `gorm:"primaryKey"`
+	Value string
+}
+
+// Cipher encrypts and decrypts message content. It's shared by pointer
+// between copies of SQLite, so unlocking one copy unlocks them all.
+type Cipher struct {
+	mu      sync.RWMutex
+	enabled bool
+	aead    cipher.AEAD
+}
+
+func (c *Cipher) Enabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.enabled
+}
+
+func (c *Cipher) Locked() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.enabled && c.aead == nil
+}
+
+func (c *Cipher) setKey(key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.enabled = true
+	c.aead = aead
+
+	return nil
+}
+
+// Encrypt encrypts the text when encryption is enabled, and fails when the
+// cipher is still locked.
+func (c *Cipher) Encrypt(text string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.enabled {
+		return text, nil
+	}
+
+	if c.aead == nil {
+		return "", ErrLocked
+	}
+
+	return seal(c.aead, text)
+}
+
+// Decrypt decrypts encrypted text and returns plain text as is. Without
+// encryption all text is plain, even when it starts like encrypted text.
+func (c *Cipher) Decrypt(text string) (string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.enabled || !strings.HasPrefix(text, encPrefix) {
+		return text, nil
+	}
+
+	if c.aead == nil {
+		return "", ErrLocked
+	}
+
+	return open(c.aead, text)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != keySize {
+		return nil, fmt.Errorf("Encryption key must be %d bytes, got %d", keySize, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func seal(aead cipher.AEAD, text string) (string, error) {
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", fmt.Errorf("Error generating nonce: %v", err)
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(text), nil)
+
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func open(aead cipher.AEAD, text string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(text, encPrefix))
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("Malformed encrypted content")
+	}
+
+	nonce, sealed := data[:aead.NonceSize()], data[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", ErrWrongKey
+	}
+
+	return string(plain), nil
+}
+
+// DeriveKey derives an encryption key from a passphrase with PBKDF2.
+func DeriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, kdfIterations, keySize)
+}
+
+// ReadKeyFile reads a raw 32 byte key, optionally followed by a newline,
+// or a hex or base64 encoded one from a key file.
+func ReadKeyFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading key file: %v", err)
+	}
+
+	if len(data) == keySize {
+		return data, nil
+	}
+
+	if raw, ok := bytes.CutSuffix(data, []byte("\n")); ok && len(raw) == keySize {
+		return raw, nil
+	}
+
+	return ParseKey(string(data))
+}
+
+// ParseKey parses a hex or base64 encoded 32 byte key, such as the value
+// of an environment variable.
+func ParseKey(encoded string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+
+	if key, err := hex.DecodeString(encoded); err == nil && len(key) == keySize {
+		return key, nil
+	}
+
+	if key, err := base64.StdEncoding.DecodeString(encoded); err == nil && len(key) == keySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("Key must be %d bytes, hex or base64 encoded", keySize)
+}
+
+// EnableEncryption requires the storage to be unlocked before use. New
+// databases are encrypted with the first passphrase or key they are
+// unlocked with, existing messages are encrypted at that point.
+//
+// Only the content of messages is encrypted. Session titles, tags, the
+// provider and model, timestamps and the message metadata, including the
+// notes of ratings, are stored in plaintext.
+func (sql SQLite) EnableEncryption() {
+	sql.cipher.mu.Lock()
+	defer sql.cipher.mu.Unlock()
+
+	sql.cipher.enabled = true
+}
+
+// Locked reports whether the storage is encrypted and waits for a key.
+func (sql SQLite) Locked() bool {
+	return sql.cipher.Locked()
+}
+
+// Unlock derives the key from the passphrase and unlocks the storage.
+func (sql SQLite) Unlock(passphrase string) error {
+	return sql.unlock(func(salt []byte) ([]byte, string, error) {
+		key, err := DeriveKey(passphrase, salt)
+		return key, fmt.Sprintf("pbkdf2-sha256:%d", kdfIterations), err
+	})
+}
+
+// UnlockWithKey unlocks the storage with a raw key, see ReadKeyFile and
+// ParseKey.
+func (sql SQLite) UnlockWithKey(key []byte) error {
+	return sql.unlock(func(salt []byte) ([]byte, string, error) {
+		return key, "raw", nil
+	})
+}
+
+// Rekey re-encrypts all messages with a key derived from the new passphrase.
+func (sql SQLite) Rekey(passphrase string) error {
+	return sql.rekey(func(salt []byte) ([]byte, string, error) {
+		key, err := DeriveKey(passphrase, salt)
+		return key, fmt.Sprintf("pbkdf2-sha256:%d", kdfIterations), err
+	})
+}
+
+// RekeyWithKey re-encrypts all messages with a new raw key.
+func (sql SQLite) RekeyWithKey(key []byte) error {
+	return sql.rekey(func(salt []byte) ([]byte, string, error) {
+		return key, "raw", nil
+	})
+}
+
+type keyFunc func(salt []byte) (key []byte, kdf string, err error)
+
+func (sql SQLite) unlock(derive keyFunc) error {
+	settings, err := sql.settings()
+	if err != nil {
+		return err
+	}
+
+	// First unlock of a plain text database, encrypt what is already stored
+	if settings[verifierSetting] == "" {
+		return sql.rekey(derive)
+	}
+
+	salt, err := base64.StdEncoding.DecodeString(settings[saltSetting])
+	if err != nil {
+		return fmt.Errorf("Malformed encryption salt: %v", err)
+	}
+
+	key, kdf, err := derive(salt)
+	if err != nil {
+		return err
+	}
+
+	if stored := settings[kdfSetting]; stored != "" && stored != kdf {
+		return kdfError(stored, kdf)
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := open(aead, settings[verifierSetting])
+	if err != nil || verifier != verifierText {
+		return ErrWrongKey
+	}
+
+	return sql.cipher.setKey(key)
+}
+
+// kdfError explains why a key made with one key derivation can't unlock a
+// storage encrypted with a key made with another.
+func kdfError(stored string, given string) error {
+	switch {
+	case stored == "raw":
+		return fmt.Errorf("Storage is encrypted with a key, unlock it with the key instead of a passphrase")
+	case given == "raw":
+		return fmt.Errorf("Storage is encrypted with a passphrase, unlock it with the passphrase instead of a key")
+	default:
+		return fmt.Errorf("Storage is encrypted with an unsupported key derivation %q", stored)
+	}
+}
+
+// rekey encrypts every message with a new key in a single transaction. The
+// current key is needed to decrypt messages that are already encrypted.
+func (sql SQLite) rekey(derive keyFunc) error {
+	if sql.cipher.Locked() {
+		settings, err := sql.settings()
+		if err != nil {
+			return err
+		}
+
+		if settings[verifierSetting] != "" {
+			return ErrLocked
+		}
+	}
+
+	salt := make([]byte, 16)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return fmt.Errorf("Error generating salt: %v", err)
+	}
+
+	key, kdf, err := derive(salt)
+	if err != nil {
+		return err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := seal(aead, verifierText)
+	if err != nil {
+		return err
+	}
+
+	err = sql.db.Transaction(func(tx *gorm.DB) error {
+		sessions := []Session{}
+		err := tx.Unscoped().Find(&sessions).Error
+		if err != nil {
+			return err
+		}
+
+		for _, session := range sessions {
+			for i, msg := range session.Msgs {
+				content, err := sql.cipher.Decrypt(msg.Content)
+				if err != nil {
+					return err
+				}
+
+				session.Msgs[i].Content, err = seal(aead, content)
+				if err != nil {
+					return err
+				}
+			}
+
+			err = tx.Unscoped().Model(&session).UpdateColumn("msgs", session.Msgs).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		for name, value := range map[string]string{
+			saltSetting:     base64.StdEncoding.EncodeToString(salt),
+			kdfSetting:      kdf,
+			verifierSetting: verifier,
+		} {
+			err := tx.Save(&Setting{Key: name, Value: value}).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("Error encrypting sessions: %v", err)
+	}
+
+	return sql.cipher.setKey(key)
+}
+
+func (sql SQLite) settings() (map[string]string, error) {
+	rows := []Setting{}
+	err := sql.db.Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("Error loading settings: %v", err)
+	}
+
+	settings := map[string]string{}
+	for _, row := range rows {
+		settings[row.Key] = row.Value
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestEncryption(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	sqliteDB := NewSQLite(dbPath)
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	session, _ := sqliteDB.NewSession()
+	err = sqliteDB.SaveMsg(session.ID, schema.Msg{Role: schema.UserMsg, Content: "secret plan"})
+	if err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+
+	// Enabling encryption on a plain text database encrypts what's stored
+	sqliteDB.EnableEncryption()
+	if !sqliteDB.Locked() {
+		t.Fatal("Expected storage to be locked")
+	}
+
+	err = sqliteDB.Unlock("correct horse")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	raw := Session{}
+	sqliteDB.db.Where("session_id = ?", session.ID).First(&raw)
+	if !strings.HasPrefix(raw.Msgs[0].Content, encPrefix) {
+		t.Errorf("Expected message to be encrypted at rest, got %q", raw.Msgs[0].Content)
+	}
+
+	err = sqliteDB.SaveMsg(session.ID, schema.Msg{Role: schema.AIMsg, Content: "keep it quiet"})
+	if err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+
+	// Reopened databases are locked until unlocked with the passphrase
+	reopened := NewSQLite(dbPath)
+	if !reopened.Locked() {
+		t.Fatal("Expected reopened storage to be locked")
+	}
+
+	err = reopened.SaveMsg(session.ID, schema.Msg{Role: schema.UserMsg, Content: "leak"})
+	if err == nil {
+		t.Error("Expected saving to fail while locked")
+	}
+
+	err = reopened.Unlock("wrong")
+	if err != ErrWrongKey {
+		t.Errorf("Expected wrong key error, got %v", err)
+	}
+
+	err = reopened.Unlock("correct horse")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, _ := reopened.LoadSession(session.ID)
+	if len(loaded.Msgs) != 2 || loaded.Msgs[0].Content != "secret plan" || loaded.Msgs[1].Content != "keep it quiet" {
+		t.Errorf("Expected decrypted messages, got %+v", loaded.Msgs)
+	}
+
+	// Re-keying switches to the new passphrase
+	err = reopened.Rekey("battery staple")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	rekeyed := NewSQLite(dbPath)
+	if rekeyed.Unlock("correct horse") != ErrWrongKey {
+		t.Error("Expected old passphrase to be rejected")
+	}
+
+	err = rekeyed.Unlock("battery staple")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	summaries, _ := rekeyed.ListSessionSummaries(0, 10)
+	if len(summaries) != 1 || summaries[0].Snippet != "keep it quiet" {
+		t.Errorf("Expected decrypted snippet, got %+v", summaries)
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	hexKey := strings.Repeat("ab", 32)
+
+	key, err := ParseKey(hexKey + "\n")
+	if err != nil || len(key) != 32 {
+		t.Errorf("Expected hex key to parse, got %v", err)
+	}
+
+	// 32 characters are decoded rather than taken as a raw key
+	key, err = ParseKey(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 24))))
+	if err == nil {
+		t.Errorf("Expected a 24 byte base64 key to be rejected, got %d bytes", len(key))
+	}
+
+	_, err = ParseKey("short")
+	if err == nil {
+		t.Error("Expected short key to be rejected")
+	}
+}
+
+func TestReadKeyFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "key_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	raw := []byte(strings.Repeat("\x01", 31) + "\n")
+
+	tests := map[string][]byte{
+		"raw":         raw,
+		"raw newline": append(append([]byte{}, raw...), '\n'),
+		"hex":         []byte(hex.EncodeToString(raw) + "\n"),
+		"base64":      []byte(base64.StdEncoding.EncodeToString(raw) + "\r\n"),
+	}
+
+	for name, data := range tests {
+		path := filepath.Join(tempDir, name)
+		os.WriteFile(path, data, 0600)
+
+		key, err := ReadKeyFile(path)
+		if err != nil || !bytes.Equal(key, raw) {
+			t.Errorf("Expected the %s key file to hold the key, got %q, %v", name, key, err)
+		}
+	}
+}
+
+func TestDecryptPlaintext(t *testing.T) {
+	text := encPrefix + "not encrypted"
+
+	plain, err := (&Cipher{}).Decrypt(text)
+	if err != nil || plain != text {
+		t.Errorf("Expected text to be plain without encryption, got %q, %v", plain, err)
+	}
+
+	_, err = (&Cipher{enabled: true}).Decrypt(text)
+	if err != ErrLocked {
+		t.Errorf("Expected locked error with encryption enabled, got %v", err)
+	}
+}
+
+func TestUnlockKeyKind(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	key := bytes.Repeat([]byte{7}, 32)
+
+	sqliteDB := NewSQLite(dbPath)
+	sqliteDB.EnableEncryption()
+
+	err = sqliteDB.UnlockWithKey(key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = NewSQLite(dbPath).Unlock("passphrase")
+	if err == nil || !strings.Contains(err.Error(), "encrypted with a key") {
+		t.Errorf("Expected the passphrase to be refused for a key, got %v", err)
+	}
+
+	err = NewSQLite(dbPath).UnlockWithKey(key)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = sqliteDB.Rekey("passphrase")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = NewSQLite(dbPath).UnlockWithKey(key)
+	if err == nil || !strings.Contains(err.Error(), "encrypted with a passphrase") {
+		t.Errorf("Expected the key to be refused for a passphrase, got %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	stdsql "database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/thanhpk/randstr"
+)
+
+// pollInterval is how often the database is checked for changes written by
+// other processes.
+const pollInterval = time.Second
+
+// Change is a row of the changes log, written alongside every change so
+// other processes using the same database can tell what changed.
+type Change struct {
+	ID        uint `gorm:"primaryKey"`
+	Instance  string
+	SessionID string
+	Kind      schema.ChangeKind
+	CreatedAt time.Time
+}
+
+// watcher fans out change events to subscribers and polls for changes made
+// by other processes. It's shared by pointer between copies of SQLite.
+type watcher struct {
+	mu          sync.Mutex
+	instance    string
+	subscribers map[int]chan schema.ChangeEvent
+	nextID      int
+	cancel      context.CancelFunc
+}
+
+func newWatcher() *watcher {
+	return &watcher{
+		instance:    randstr.String(12),
+		subscribers: map[int]chan schema.ChangeEvent{},
+	}
+}
+
+func (w *watcher) broadcast(event schema.ChangeEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, ch := range w.subscribers {
+		// Slow subscribers miss events rather than block writers
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel of change events, both from this process and
+// from other processes writing to the same database file.
+func (sql SQLite) Subscribe() (<-chan schema.ChangeEvent, func()) {
+	w := sql.watcher
+	ch := make(chan schema.ChangeEvent, 64)
+
+	w.mu.Lock()
+	id := w.nextID
+	w.nextID++
+	w.subscribers[id] = ch
+
+	if w.cancel == nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		w.cancel = cancel
+		sql.startPolling(ctx)
+	}
+	w.mu.Unlock()
+
+	unsubscribe := func() {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+
+		if _, ok := w.subscribers[id]; !ok {
+			return
+		}
+
+		delete(w.subscribers, id)
+		close(ch)
+
+		if len(w.subscribers) == 0 && w.cancel != nil {
+			w.cancel()
+			w.cancel = nil
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+// publish records the change in the changes log and notifies subscribers
+// in this process.
+func (sql SQLite) publish(kind schema.ChangeKind, sessionID string) {
+	err := sql.db.Create(&Change{
+		Instance:  sql.watcher.instance,
+		SessionID: sessionID,
+		Kind:      kind,
+	}).Error
+	if err != nil {
+		log.Printf("Error recording change: %v", err)
+	}
+
+	sql.watcher.broadcast(schema.ChangeEvent{Kind: kind, SessionID: sessionID})
+}
+
+// startPolling records where the changes log is at and starts polling it.
+// The starting point is read before returning so no change made after
+// subscribing is missed.
+func (sql SQLite) startPolling(ctx context.Context) {
+	sqlDB, err := sql.db.DB()
+	if err != nil {
+		log.Printf("Error getting DB for polling: %v", err)
+		return
+	}
+
+	// data_version is per connection, so it has to be read from the same one
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("Error opening polling connection: %v", err)
+		return
+	}
+
+	version := dataVersion(ctx, conn)
+
+	var lastID uint
+	sql.db.Model(&Change{}).Select("COALESCE(MAX(id), 0)").Scan(&lastID)
+
+	go sql.poll(ctx, conn, version, lastID)
+}
+
+// poll watches PRAGMA data_version, which changes when another connection
+// commits, and reads the changes log written by other processes.
+func (sql SQLite) poll(ctx context.Context, conn *stdsql.Conn, version int64, lastID uint) {
+	defer conn.Close()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current := dataVersion(ctx, conn)
+		if current == version {
+			continue
+		}
+		version = current
+
+		changes := []Change{}
+		err := sql.db.Where("id > ?", lastID).Order("id").Find(&changes).Error
+		if err != nil {
+			log.Printf("Error reading changes: %v", err)
+			continue
+		}
+
+		for _, change := range changes {
+			lastID = change.ID
+
+			if change.Instance == sql.watcher.instance {
+				continue
+			}
+
+			sql.watcher.broadcast(schema.ChangeEvent{
+				Kind:      change.Kind,
+				SessionID: change.SessionID,
+				External:  true,
+			})
+		}
+	}
+}
+
+func dataVersion(ctx context.Context, conn *stdsql.Conn) int64 {
+	var version int64
+	err := conn.QueryRowContext(ctx, "PRAGMA data_version").Scan(&version)
+	if err != nil && ctx.Err() == nil {
+		log.Printf("Error reading data version: %v", err)
+	}
+
+	return version
+}
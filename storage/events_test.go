@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestSubscribeExternalChanges(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+	watching := NewSQLite(dbPath)
+	writing := NewSQLite(dbPath)
+	if watching == nil || writing == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	changes, unsubscribe := watching.Subscribe()
+	defer unsubscribe()
+
+	// Local changes are delivered too, but not flagged as external
+	local, err := watching.NewSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	event := <-changes
+	if event.External || event.SessionID != local.ID || event.Kind != schema.SessionCreated {
+		t.Fatalf("Unexpected local event: %+v", event)
+	}
+
+	err = writing.SaveMsg(local.ID, schema.Msg{Role: schema.UserMsg, Content: "hello"})
+	if err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+
+	select {
+	case event := <-changes:
+		if !event.External || event.SessionID != local.ID || event.Kind != schema.MsgAppended {
+			t.Errorf("Unexpected external event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected an external change event")
+	}
+}
@@ -0,0 +1,248 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/thanhpk/randstr"
+	"gorm.io/gorm"
+)
+
+// Migration is a single, ordered change to the database schema. Migrations
+// are applied in a transaction and recorded in the schema_migrations table,
+// once released their version and behaviour must not change.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+}
+
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+// sessionV1 is the sessions table as it was first created by AutoMigrate.
+type sessionV1 struct {
+	gorm.Model
+	SessionID string
+	Title     string
+	Msgs      Messages `gorm:"type:jsonb;column:msgs"`
+}
+
+func (sessionV1) TableName() string { return "sessions" }
+
+var migrations = []Migration{
+	{
+		Version: 1,
+		Name:    "create sessions",
+		Up: func(tx *gorm.DB) error {
+			// Databases created before versioning already have the table
+			if tx.Migrator().HasTable("sessions") {
+				return nil
+			}
+
+			return tx.Migrator().CreateTable(&sessionV1{})
+		},
+	},
+	{
+		Version: 2,
+		Name:    "add session parent",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, "sessions", map[string]string{
+				"parent_id": "text NOT NULL DEFAULT ''",
+			})
+		},
+	},
+	{
+		Version: 3,
+		Name:    "add session metadata",
+		Up: func(tx *gorm.DB) error {
+			return addColumns(tx, "sessions", map[string]string{
+				"provider": "text NOT NULL DEFAULT ''",
+				"model":    "text NOT NULL DEFAULT ''",
+				"tags":     "jsonb NOT NULL DEFAULT '[]'",
+				"pinned":   "numeric NOT NULL DEFAULT false",
+			})
+		},
+	},
+	{
+		Version: 4,
+		Name:    "index session ids",
+		Up: func(tx *gorm.DB) error {
+			return tx.Exec("CREATE INDEX IF NOT EXISTS idx_sessions_session_id ON sessions(session_id)").Error
+		},
+	},
+	{
+		Version: 5,
+		Name:    "create settings",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Setting{})
+		},
+	},
+	{
+		Version: 6,
+		Name:    "create changes log",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&Change{})
+		},
+	},
+	{
+		Version: 7,
+		Name:    "assign message ids",
+		Up:      assignMsgIDs,
+	},
+}
+
+// SchemaVersion returns the version of the newest migration the binary knows.
+func SchemaVersion() int {
+	return migrations[len(migrations)-1].Version
+}
+
+// migrate brings the database up to the latest schema version. It refuses
+// to touch databases written by a newer binary, and makes a backup copy of
+// existing databases before applying pending migrations.
+func migrate(db *gorm.DB, dbPath string) error {
+	err := db.AutoMigrate(&SchemaMigration{})
+	if err != nil {
+		return fmt.Errorf("Error creating schema_migrations table: %v", err)
+	}
+
+	current, err := currentVersion(db)
+	if err != nil {
+		return err
+	}
+
+	latest := SchemaVersion()
+	if current > latest {
+		return fmt.Errorf("Database schema version %d is newer than supported version %d, update clipt", current, latest)
+	}
+
+	if current == latest {
+		return nil
+	}
+
+	if db.Migrator().HasTable("sessions") {
+		backup, err := backupDB(db, dbPath, current)
+		if err != nil {
+			return err
+		}
+
+		if backup != "" {
+			log.Printf("Backed up database to %s before migrating", backup)
+		}
+	}
+
+	for _, migration := range migrations {
+		if migration.Version <= current {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			err := migration.Up(tx)
+			if err != nil {
+				return err
+			}
+
+			return tx.Create(&SchemaMigration{
+				Version:   migration.Version,
+				Name:      migration.Name,
+				AppliedAt: time.Now(),
+			}).Error
+		})
+
+		if err != nil {
+			return fmt.Errorf("Error applying migration %d (%s): %v", migration.Version, migration.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func currentVersion(db *gorm.DB) (int, error) {
+	var version int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&version).Error
+	if err != nil {
+		return 0, fmt.Errorf("Error reading schema version: %v", err)
+	}
+
+	return version, nil
+}
+
+// backupDB writes a consistent copy of the database next to it and returns
+// its path. In-memory databases are not backed up.
+func backupDB(db *gorm.DB, dbPath string, version int) (string, error) {
+	if dbPath == "" || dbPath == ":memory:" {
+		return "", nil
+	}
+
+	if _, err := os.Stat(dbPath); err != nil {
+		return "", nil
+	}
+
+	backup := fmt.Sprintf("%s.v%d-%s.bak", dbPath, version, time.Now().Format("20060102150405"))
+	err := db.Exec("VACUUM INTO ?", backup).Error
+	if err != nil {
+		return "", fmt.Errorf("Error backing up database before migrating: %v", err)
+	}
+
+	return backup, nil
+}
+
+// assignMsgIDs gives every stored message a stable ID. Messages are handled
+// as raw JSON so later changes to Message don't affect the migration.
+func assignMsgIDs(tx *gorm.DB) error {
+	rows := []struct {
+		ID   uint
+		Msgs string
+	}{}
+
+	err := tx.Table("sessions").Select("id, msgs").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		msgs := []map[string]any{}
+		if row.Msgs == "" || json.Unmarshal([]byte(row.Msgs), &msgs) != nil {
+			continue
+		}
+
+		for _, msg := range msgs {
+			if id, _ := msg["ID"].(string); id == "" {
+				msg["ID"] = randstr.String(12)
+			}
+		}
+
+		data, err := json.Marshal(msgs)
+		if err != nil {
+			return err
+		}
+
+		err = tx.Table("sessions").Where("id = ?", row.ID).UpdateColumn("msgs", string(data)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func addColumns(tx *gorm.DB, table string, columns map[string]string) error {
+	for name, kind := range columns {
+		if tx.Migrator().HasColumn(table, name) {
+			continue
+		}
+
+		err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, name, kind)).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	// Database as created by releases that relied on AutoMigrate
+	legacy, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open legacy DB: %v", err)
+	}
+
+	err = legacy.AutoMigrate(&sessionV1{})
+	if err != nil {
+		t.Fatalf("Failed to create legacy schema: %v", err)
+	}
+
+	err = legacy.Create(&sessionV1{SessionID: "legacy01", Title: "Legacy", Msgs: Messages{{Role: "UserMsg", Content: "Hi"}}}).Error
+	if err != nil {
+		t.Fatalf("Failed to create legacy session: %v", err)
+	}
+
+	sqlDB, _ := legacy.DB()
+	sqlDB.Close()
+
+	sqliteDB := NewSQLite(dbPath)
+	if sqliteDB == nil {
+		t.Fatal("Failed to open and migrate legacy DB")
+	}
+
+	version, err := currentVersion(sqliteDB.db)
+	if err != nil || version != SchemaVersion() {
+		t.Errorf("Expected schema version %d, got %d, %v", SchemaVersion(), version, err)
+	}
+
+	session, err := sqliteDB.LoadSession("legacy01")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if session.Title != "Legacy" || len(session.Msgs) != 1 || session.Pinned || len(session.Tags) != 0 {
+		t.Errorf("Legacy session not migrated correctly: %+v", session)
+	}
+
+	backups, _ := filepath.Glob(dbPath + ".v0-*.bak")
+	if len(backups) != 1 {
+		t.Errorf("Expected a backup before migrating, got %v", backups)
+	}
+
+	// Reopening an up to date database doesn't make another backup
+	if NewSQLite(dbPath) == nil {
+		t.Fatal("Failed to reopen migrated DB")
+	}
+
+	backups, _ = filepath.Glob(dbPath + ".*.bak")
+	if len(backups) != 1 {
+		t.Errorf("Expected no new backup, got %v", backups)
+	}
+}
+
+func TestRefuseNewerDatabase(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dbPath := filepath.Join(tempDir, "test.db")
+
+	sqliteDB := NewSQLite(dbPath)
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	err = sqliteDB.db.Create(&SchemaMigration{Version: SchemaVersion() + 1, Name: "from the future"}).Error
+	if err != nil {
+		t.Fatalf("Failed to record future migration: %v", err)
+	}
+
+	if NewSQLite(dbPath) != nil {
+		t.Error("Expected newer database to be refused")
+	}
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// ApplyRetention permanently deletes the sessions, including the ones in
+// the trash, that fall outside the policy and vacuums the database. Sessions
+// are ranked by their last activity, the most recent ones are kept, and the
+// trash only keeps what the live sessions leave of the limits. On a dry run
+// only the report is returned.
+func (sql SQLite) ApplyRetention(policy schema.RetentionPolicy, dryRun bool) (schema.RetentionReport, error) {
+	report := schema.RetentionReport{DryRun: dryRun, Removed: []schema.SessionSummary{}}
+
+	if !policy.Enabled() {
+		return report, nil
+	}
+
+	rows := []struct {
+		ID        uint
+		SessionID string
+		Title     string
+		Pinned    bool
+		Size      int64
+		CreatedAt time.Time
+		UpdatedAt time.Time
+	}{}
+
+	err := sql.db.Unscoped().Model(&Session{}).
+		Select("id, session_id, title, pinned, created_at, updated_at, COALESCE(length(msgs), 0) AS size").
+		Order("deleted_at IS NOT NULL, updated_at DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return report, fmt.Errorf("Error loading sessions for retention: %v", err)
+	}
+
+	cutoff := time.Now().Add(-policy.MaxAge)
+	kept := 0
+	keptSize := int64(0)
+	ids := []uint{}
+
+	for _, row := range rows {
+		if policy.ExcludePinned && row.Pinned {
+			continue
+		}
+
+		remove := policy.MaxAge > 0 && row.UpdatedAt.Before(cutoff)
+		remove = remove || policy.MaxSessions > 0 && kept >= policy.MaxSessions
+		remove = remove || policy.MaxTotalSize > 0 && keptSize+row.Size > policy.MaxTotalSize
+
+		if !remove {
+			kept++
+			keptSize += row.Size
+			continue
+		}
+
+		ids = append(ids, row.ID)
+		report.Bytes += row.Size
+		report.Removed = append(report.Removed, schema.SessionSummary{
+			ID:        row.SessionID,
+			Title:     row.Title,
+			Pinned:    row.Pinned,
+			CreatedAt: row.CreatedAt.Unix(),
+			UpdatedAt: row.UpdatedAt.Unix(),
+		})
+	}
+
+	if dryRun || len(ids) == 0 {
+		return report, nil
+	}
+
+	err = sql.db.Unscoped().Where("id IN ?", ids).Delete(&Session{}).Error
+	if err != nil {
+		return report, fmt.Errorf("Error deleting sessions for retention: %v", err)
+	}
+
+	for _, session := range report.Removed {
+		sql.publish(schema.SessionDeleted, session.ID)
+	}
+
+	// Give the space of the deleted sessions back to the file system
+	err = sql.db.Exec("VACUUM").Error
+	if err != nil {
+		return report, fmt.Errorf("Error vacuuming database: %v", err)
+	}
+
+	return report, nil
+}
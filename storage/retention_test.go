@@ -0,0 +1,128 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/thanhpk/randstr"
+)
+
+func TestApplyRetention(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	now := time.Now()
+	sessions := []Session{
+		{SessionID: randstr.String(8), Title: "recent"},
+		{SessionID: randstr.String(8), Title: "last week"},
+		{SessionID: randstr.String(8), Title: "old"},
+		{SessionID: randstr.String(8), Title: "old pinned", Pinned: true},
+	}
+	ages := []time.Duration{time.Hour, 7 * 24 * time.Hour, 120 * 24 * time.Hour, 200 * 24 * time.Hour}
+
+	for i := range sessions {
+		sessions[i].Msgs = Messages{{Role: "UserMsg", Content: "hello"}}
+		sessions[i].CreatedAt = now.Add(-ages[i])
+		sessions[i].UpdatedAt = now.Add(-ages[i])
+
+		err = sqliteDB.db.Create(&sessions[i]).Error
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	policy := schema.RetentionPolicy{MaxAge: 90 * 24 * time.Hour, ExcludePinned: true}
+
+	report, err := sqliteDB.ApplyRetention(policy, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0].Title != "old" {
+		t.Errorf("Expected only the old unpinned session in the report, got %+v", report.Removed)
+	}
+	if len(sqliteDB.ListSessions()) != 4 {
+		t.Error("Expected dry run to keep all sessions")
+	}
+
+	_, err = sqliteDB.ApplyRetention(policy, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sqliteDB.ListSessions()) != 3 {
+		t.Errorf("Expected 3 sessions left, got %d", len(sqliteDB.ListSessions()))
+	}
+
+	report, err = sqliteDB.ApplyRetention(schema.RetentionPolicy{MaxSessions: 1}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	left := sqliteDB.ListSessions()
+	if len(report.Removed) != 2 || len(left) != 1 || left[0].Title != "recent" {
+		t.Errorf("Expected only the most recent session kept, got %+v", left)
+	}
+}
+
+func TestApplyRetentionTrash(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	now := time.Now()
+	sessions := []Session{
+		{SessionID: randstr.String(8), Title: "trashed"},
+		{SessionID: randstr.String(8), Title: "trashed too"},
+		{SessionID: randstr.String(8), Title: "live"},
+		{SessionID: randstr.String(8), Title: "older live"},
+	}
+
+	for i := range sessions {
+		sessions[i].Msgs = Messages{{Role: "UserMsg", Content: "hello"}}
+		sessions[i].CreatedAt = now.Add(-time.Duration(i+1) * time.Hour)
+		sessions[i].UpdatedAt = sessions[i].CreatedAt
+
+		err = sqliteDB.db.Create(&sessions[i]).Error
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	// The trashed sessions are newer than the live ones
+	for _, session := range sessions[:2] {
+		err = sqliteDB.db.Delete(&Session{}, session.ID).Error
+		if err != nil {
+			t.Fatalf("Failed to trash session: %v", err)
+		}
+	}
+
+	report, err := sqliteDB.ApplyRetention(schema.RetentionPolicy{MaxSessions: 3}, false)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Removed) != 1 || report.Removed[0].Title != "trashed too" {
+		t.Errorf("Expected only the older trashed session removed, got %+v", report.Removed)
+	}
+
+	if len(sqliteDB.ListSessions()) != 2 {
+		t.Errorf("Expected both live sessions kept, got %+v", sqliteDB.ListSessions())
+	}
+}
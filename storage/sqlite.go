@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"strings"
+	"time"
 
 	"github.com/struki84/clipt/tui/schema"
 	"gorm.io/driver/sqlite"
@@ -15,19 +16,30 @@ import (
 	"github.com/thanhpk/randstr"
 )
 
+// Session is the stored session record, changes to its fields need a new
+// migration in migrations.go.
 type Session struct {
 	gorm.Model
 	SessionID string
+	ParentID  string
 	Title     string
+	Provider  string
+	ModelName string `gorm:"column:model"`
+	Tags      Tags   `gorm:"type:jsonb;column:tags"`
+	Pinned    bool
 	Msgs      Messages `gorm:"type:jsonb;column:msgs"`
 }
 
 type Messages []Message
 
+type Tags []string
+
 type Message struct {
+	ID        string
 	Role      string
 	Content   string
 	Timestamp int64
+	Meta      map[string]string `json:",omitempty"`
 }
 
 func (m Messages) Value() (driver.Value, error) {
@@ -47,10 +59,36 @@ func (m *Messages) Scan(src any) error {
 	return json.Unmarshal(bytes, m)
 }
 
+func (t Tags) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+
+	return json.Marshal(t)
+}
+
+func (t *Tags) Scan(src any) error {
+	var bytes []byte
+	switch v := src.(type) {
+	case nil:
+		*t = Tags{}
+		return nil
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return errors.New("could not scan type into Tags")
+	}
+	return json.Unmarshal(bytes, t)
+}
+
 type SQLite struct {
-	db     *gorm.DB
-	path   string
-	record Session
+	db      *gorm.DB
+	path    string
+	record  Session
+	cipher  *Cipher
+	watcher *watcher
 }
 
 func NewSQLite(dbPath string) *SQLite {
@@ -69,16 +107,34 @@ func NewSQLite(dbPath string) *SQLite {
 	sqlDB.Exec("PRAGMA foreign_keys = ON;")
 	sqlDB.Exec("PRAGMA journal_mode = WAL;")
 
-	err = db.AutoMigrate(Session{})
+	err = migrate(db, dbPath)
 	if err != nil {
 		log.Printf("Error migrating DB: %v", err)
 		return nil
 	}
 
-	return &SQLite{
-		db:   db,
-		path: dbPath,
+	sql := &SQLite{
+		db:      db,
+		path:    dbPath,
+		cipher:  &Cipher{},
+		watcher: newWatcher(),
+	}
+
+	// The changes log only has to outlive the polling interval of others
+	db.Where("created_at < ?", time.Now().Add(-24*time.Hour)).Delete(&Change{})
+
+	settings, err := sql.settings()
+	if err != nil {
+		log.Printf("Error loading DB settings: %v", err)
+		return nil
+	}
+
+	// Encrypted databases stay locked until unlocked with their key
+	if settings[verifierSetting] != "" {
+		sql.EnableEncryption()
 	}
+
+	return sql
 }
 
 func (sql SQLite) NewSession() (schema.ChatSession, error) {
@@ -96,6 +152,8 @@ func (sql SQLite) NewSession() (schema.ChatSession, error) {
 		return schema.ChatSession{}, fmt.Errorf("Error creating new session, %v", err)
 	}
 
+	sql.publish(schema.SessionCreated, sessionID)
+
 	return schema.ChatSession{
 		ID:        sessionID,
 		Title:     sql.record.Title,
@@ -106,30 +164,67 @@ func (sql SQLite) NewSession() (schema.ChatSession, error) {
 
 func (sql SQLite) ListSessions() []schema.ChatSession {
 	sessions := []Session{}
-	err := sql.db.Find(&sessions).Error
+	err := sql.db.Order("pinned DESC, updated_at DESC").Find(&sessions).Error
 	if err != nil {
 		return []schema.ChatSession{}
 	}
 
 	list := []schema.ChatSession{}
 	for _, session := range sessions {
-		msgs := []schema.Msg{}
-		for _, msg := range session.Msgs {
-			msgs = append(msgs, schema.Msg{
-				Role:    schema.EnumRole(msg.Role),
-				Content: msg.Content,
-			})
-		}
+		list = append(list, sql.chatSession(session))
+	}
+
+	return list
+}
 
-		list = append(list, schema.ChatSession{
-			ID:        session.SessionID,
-			Title:     session.Title,
-			Msgs:      msgs,
-			CreatedAt: session.CreatedAt.Unix(),
+// ListSessionSummaries lists a page of sessions, pinned first and then by
+// most recent activity, without loading their messages.
+func (sql SQLite) ListSessionSummaries(offset int, limit int) ([]schema.SessionSummary, error) {
+	rows := []struct {
+		SessionID   string
+		ParentID    string
+		ParentTitle string
+		Title       string
+		Provider    string
+		Tags        Tags
+		Pinned      bool
+		MsgCount    int
+		Snippet     string
+		CreatedAt   time.Time
+		UpdatedAt   time.Time
+	}{}
+
+	err := sql.db.Model(&Session{}).
+		Select(`session_id, parent_id, title, provider, tags, pinned, created_at, updated_at,
+			COALESCE((SELECT p.title FROM sessions p WHERE p.session_id = sessions.parent_id AND p.deleted_at IS NULL LIMIT 1), '') AS parent_title,
+			COALESCE(json_array_length(msgs), 0) AS msg_count,
+			COALESCE(json_extract(msgs, '$[#-1].Content'), '') AS snippet`).
+		Order("pinned DESC, updated_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("Error listing sessions: %v", err)
+	}
+
+	summaries := []schema.SessionSummary{}
+	for _, row := range rows {
+		summaries = append(summaries, schema.SessionSummary{
+			ID:          row.SessionID,
+			ParentID:    row.ParentID,
+			ParentTitle: row.ParentTitle,
+			Title:       row.Title,
+			Provider:    row.Provider,
+			Tags:        append([]string{}, row.Tags...),
+			Pinned:      row.Pinned,
+			MsgCount:    row.MsgCount,
+			Snippet:     truncate(sql.decrypt(row.Snippet), 200),
+			CreatedAt:   row.CreatedAt.Unix(),
+			UpdatedAt:   row.UpdatedAt.Unix(),
 		})
 	}
 
-	return list
+	return summaries, nil
 }
 
 func (sql SQLite) LoadRecentSession() (schema.ChatSession, error) {
@@ -140,26 +235,8 @@ func (sql SQLite) LoadRecentSession() (schema.ChatSession, error) {
 	}
 
 	if len(sessions) > 0 {
-		session := sessions[0]
-
-		msgs := []schema.Msg{}
-		for _, msg := range session.Msgs {
-			msgs = append(msgs, schema.Msg{
-				Role:      schema.EnumRole(msg.Role),
-				Content:   msg.Content,
-				Timestamp: msg.Timestamp,
-			})
-		}
-
-		sql.record = session
-
-		return schema.ChatSession{
-			ID:        sql.record.SessionID,
-			Title:     sql.record.Title,
-			Msgs:      msgs,
-			CreatedAt: sql.record.CreatedAt.Unix(),
-		}, nil
-
+		sql.record = sessions[0]
+		return sql.chatSession(sql.record), nil
 	}
 
 	sessionID := randstr.String(8)
@@ -174,12 +251,9 @@ func (sql SQLite) LoadRecentSession() (schema.ChatSession, error) {
 		return schema.ChatSession{}, fmt.Errorf("Error loading recent sessions, %v", err)
 	}
 
-	return schema.ChatSession{
-		ID:        sql.record.SessionID,
-		Title:     sql.record.Title,
-		Msgs:      []schema.Msg{},
-		CreatedAt: sql.record.CreatedAt.Unix(),
-	}, nil
+	sql.publish(schema.SessionCreated, sessionID)
+
+	return sql.chatSession(sql.record), nil
 }
 
 func (sql SQLite) LoadSession(sessionID string) (schema.ChatSession, error) {
@@ -188,74 +262,329 @@ func (sql SQLite) LoadSession(sessionID string) (schema.ChatSession, error) {
 		return schema.ChatSession{}, fmt.Errorf("Error loading session: %v", err)
 	}
 
-	msgs := []schema.Msg{}
-	for _, msg := range sql.record.Msgs {
-		msgs = append(msgs, schema.Msg{
-			Role:      schema.EnumRole(msg.Role),
-			Content:   msg.Content,
-			Timestamp: msg.Timestamp,
-		})
-	}
-
-	return schema.ChatSession{
-		ID:        sql.record.SessionID,
-		Title:     sql.record.Title,
-		Msgs:      msgs,
-		CreatedAt: sql.record.CreatedAt.Unix(),
-	}, nil
+	return sql.chatSession(sql.record), nil
 }
 
+// SaveSession creates or replaces the session with the same ID. Sessions
+// that don't exist yet keep their original creation time, which lets
+// imported sessions retain their timestamps. A session in the trash is
+// updated there and stays trashed until it is restored.
 func (sql SQLite) SaveSession(session schema.ChatSession) (schema.ChatSession, error) {
 	msgs := Messages{}
-	for i, msg := range session.Msgs {
-		msgs[i] = Message{
+	for _, msg := range session.Msgs {
+		content, err := sql.cipher.Encrypt(msg.Content)
+		if err != nil {
+			return schema.ChatSession{}, fmt.Errorf("Can't save session, %v", err)
+		}
+
+		msgs = append(msgs, Message{
+			ID:        msgID(msg.ID),
 			Role:      msg.Role.String(),
-			Content:   msg.Content,
+			Content:   content,
 			Timestamp: msg.Timestamp,
+			Meta:      msg.Meta,
+		})
+	}
+
+	sql.record = Session{}
+	err := sql.db.Unscoped().Where("session_id = ?", session.ID).Limit(1).Find(&sql.record).Error
+	if err != nil {
+		return schema.ChatSession{}, fmt.Errorf("Can't save session, %v", err)
+	}
+
+	if sql.record.ID == 0 && session.CreatedAt != 0 {
+		sql.record.CreatedAt = time.Unix(session.CreatedAt, 0)
+		sql.record.UpdatedAt = sql.record.CreatedAt
+
+		if len(msgs) > 0 && msgs[len(msgs)-1].Timestamp != 0 {
+			sql.record.UpdatedAt = time.Unix(msgs[len(msgs)-1].Timestamp, 0)
 		}
 	}
 
+	sql.record.SessionID = session.ID
+	sql.record.ParentID = session.ParentID
+	sql.record.Title = session.Title
+	sql.record.Provider = session.Provider
+	sql.record.ModelName = session.Model
+	sql.record.Tags = session.Tags
+	sql.record.Pinned = session.Pinned
+	sql.record.Msgs = msgs
+
+	created := sql.record.ID == 0
+
+	err = sql.db.Unscoped().Save(&sql.record).Error
+	if err != nil {
+		return schema.ChatSession{}, fmt.Errorf("Can't save session, %v", err)
+	}
+
+	if created {
+		sql.publish(schema.SessionCreated, session.ID)
+	} else {
+		sql.publish(schema.SessionUpdated, session.ID)
+	}
+
+	session.CreatedAt = sql.record.CreatedAt.Unix()
+	session.UpdatedAt = sql.record.UpdatedAt.Unix()
+
+	return session, nil
+}
+
+// ForkSession copies the messages of the session up to and including the
+// message at index uptoMsg into a new session linked back to its parent. A
+// negative index copies the whole history, an index past the last message
+// is an error.
+func (sql SQLite) ForkSession(sessionID string, uptoMsg int) (schema.ChatSession, error) {
+	parent := Session{}
+	err := sql.db.Where("session_id = ?", sessionID).First(&parent).Error
+	if err != nil {
+		return schema.ChatSession{}, fmt.Errorf("Error loading session: %v", err)
+	}
+
+	count := len(parent.Msgs)
+	if uptoMsg >= count {
+		return schema.ChatSession{}, fmt.Errorf("Error forking session: no message %d", uptoMsg)
+	}
+
+	if uptoMsg >= 0 {
+		count = uptoMsg + 1
+	}
+
+	msgs := make(Messages, count)
+	copy(msgs, parent.Msgs[:count])
+
+	newSessionID := randstr.String(8)
 	sql.record = Session{
-		SessionID: session.ID,
-		Title:     session.Title,
+		SessionID: newSessionID,
+		ParentID:  parent.SessionID,
+		Title:     fmt.Sprintf("%s (fork)", parent.Title),
+		Provider:  parent.Provider,
+		ModelName: parent.ModelName,
+		Tags:      append(Tags{}, parent.Tags...),
 		Msgs:      msgs,
 	}
 
-	err := sql.db.Save(&sql.record).Error
+	err = sql.db.Create(&sql.record).Error
 	if err != nil {
-		return schema.ChatSession{}, fmt.Errorf("Can't save session, %v", err)
+		return schema.ChatSession{}, fmt.Errorf("Error forking session, %v", err)
 	}
 
-	return session, nil
+	sql.publish(schema.SessionCreated, newSessionID)
+
+	return sql.chatSession(sql.record), nil
 }
 
+func (sql SQLite) RenameSession(sessionID string, title string) error {
+	err := sql.db.Model(&Session{}).Where("session_id = ?", sessionID).Update("title", title).Error
+	if err != nil {
+		return fmt.Errorf("Error renaming session: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
+	return nil
+}
+
+// SetSessionProvider records the provider and model the session is used with.
+func (sql SQLite) SetSessionProvider(sessionID string, provider string, model string) error {
+	err := sql.db.Model(&Session{}).Where("session_id = ?", sessionID).Updates(map[string]any{
+		"provider": provider,
+		"model":    model,
+	}).Error
+	if err != nil {
+		return fmt.Errorf("Error updating session provider: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
+	return nil
+}
+
+func (sql SQLite) SetSessionTags(sessionID string, tags []string) error {
+	err := sql.db.Model(&Session{}).Where("session_id = ?", sessionID).UpdateColumn("tags", Tags(tags)).Error
+	if err != nil {
+		return fmt.Errorf("Error updating session tags: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
+	return nil
+}
+
+func (sql SQLite) PinSession(sessionID string, pinned bool) error {
+	err := sql.db.Model(&Session{}).Where("session_id = ?", sessionID).UpdateColumn("pinned", pinned).Error
+	if err != nil {
+		return fmt.Errorf("Error pinning session: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
+	return nil
+}
+
+// DeleteSession moves the session to the trash, it can be brought back with
+// RestoreSession until it is purged.
 func (sql SQLite) DeleteSession(sessionID string) error {
-	err := sql.db.Where("session_id = ?", sessionID).Delete(&sql.record)
+	err := sql.db.Where("session_id = ?", sessionID).Delete(&Session{}).Error
 	if err != nil {
 		return fmt.Errorf("Error deleting session: %v ", err)
 	}
+
+	sql.publish(schema.SessionDeleted, sessionID)
+	return nil
+}
+
+func (sql SQLite) ListTrash() []schema.ChatSession {
+	sessions := []Session{}
+	err := sql.db.Unscoped().Where("deleted_at IS NOT NULL").Order("deleted_at DESC").Find(&sessions).Error
+	if err != nil {
+		return []schema.ChatSession{}
+	}
+
+	list := []schema.ChatSession{}
+	for _, session := range sessions {
+		list = append(list, sql.chatSession(session))
+	}
+
+	return list
+}
+
+func (sql SQLite) RestoreSession(sessionID string) error {
+	err := sql.db.Unscoped().Model(&Session{}).Where("session_id = ?", sessionID).Update("deleted_at", nil).Error
+	if err != nil {
+		return fmt.Errorf("Error restoring session: %v", err)
+	}
+
+	sql.publish(schema.SessionCreated, sessionID)
+
+	return nil
+}
+
+// PurgeSession permanently deletes a session from the trash.
+func (sql SQLite) PurgeSession(sessionID string) error {
+	err := sql.db.Unscoped().Where("session_id = ? AND deleted_at IS NOT NULL", sessionID).Delete(&Session{}).Error
+	if err != nil {
+		return fmt.Errorf("Error purging session: %v", err)
+	}
+
+	sql.publish(schema.SessionDeleted, sessionID)
+
 	return nil
 }
 
+// PurgeTrash permanently deletes sessions that have been in the trash for
+// longer than olderThan, or the whole trash when olderThan is zero.
+func (sql SQLite) PurgeTrash(olderThan time.Duration) (int, error) {
+	result := sql.db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-olderThan)).
+		Delete(&Session{})
+
+	if result.Error != nil {
+		return 0, fmt.Errorf("Error purging trash: %v", result.Error)
+	}
+
+	return int(result.RowsAffected), nil
+}
+
 func (sql SQLite) SaveMsg(sessionID string, msg schema.Msg) error {
-	err := sql.db.Where("session_id = ?", sessionID).Find(&sql.record).Error
+	err := sql.db.Unscoped().Where("session_id = ?", sessionID).Find(&sql.record).Error
 	if err != nil {
 		return fmt.Errorf("Error loading session: %v", err)
 	}
 
+	content, err := sql.cipher.Encrypt(msg.Content)
+	if err != nil {
+		return fmt.Errorf("Can't save message, %v", err)
+	}
+
 	message := Message{
+		ID:        msgID(msg.ID),
 		Role:      msg.Role.String(),
-		Content:   msg.Content,
+		Content:   content,
 		Timestamp: msg.Timestamp,
+		Meta:      msg.Meta,
 	}
 
 	sql.record.Msgs = append(sql.record.Msgs, message)
 
-	err = sql.db.Save(&sql.record).Error
+	err = sql.db.Unscoped().Save(&sql.record).Error
 	if err != nil {
 		return fmt.Errorf("Can't save session, %v", err)
 	}
 
+	sql.publish(schema.MsgAppended, sessionID)
+
+	return nil
+}
+
+// AnnotateMsg merges the metadata into the message's metadata, empty values
+// remove their key.
+func (sql SQLite) AnnotateMsg(sessionID string, msgID string, meta schema.MsgMeta) error {
+	err := sql.db.Where("session_id = ?", sessionID).First(&sql.record).Error
+	if err != nil {
+		return fmt.Errorf("Error loading session: %v", err)
+	}
+
+	found := false
+	for i, msg := range sql.record.Msgs {
+		if msg.ID != msgID {
+			continue
+		}
+
+		if msg.Meta == nil {
+			msg.Meta = map[string]string{}
+		}
+
+		for key, value := range meta {
+			if value == "" {
+				delete(msg.Meta, key)
+			} else {
+				msg.Meta[key] = value
+			}
+		}
+
+		sql.record.Msgs[i] = msg
+		found = true
+	}
+
+	if !found {
+		return fmt.Errorf("Error annotating message: message %s not found", msgID)
+	}
+
+	err = sql.db.Model(&sql.record).UpdateColumn("msgs", sql.record.Msgs).Error
+	if err != nil {
+		return fmt.Errorf("Error annotating message: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
+	return nil
+}
+
+// DeleteMsg removes the message from the session.
+func (sql SQLite) DeleteMsg(sessionID string, msgID string) error {
+	err := sql.db.Where("session_id = ?", sessionID).First(&sql.record).Error
+	if err != nil {
+		return fmt.Errorf("Error loading session: %v", err)
+	}
+
+	msgs := Messages{}
+	for _, msg := range sql.record.Msgs {
+		if msg.ID != msgID {
+			msgs = append(msgs, msg)
+		}
+	}
+
+	if len(msgs) == len(sql.record.Msgs) {
+		return fmt.Errorf("Error deleting message: message %s not found", msgID)
+	}
+
+	err = sql.db.Model(&sql.record).UpdateColumn("msgs", msgs).Error
+	if err != nil {
+		return fmt.Errorf("Error deleting message: %v", err)
+	}
+
+	sql.publish(schema.SessionUpdated, sessionID)
+
 	return nil
 }
 
@@ -268,9 +597,90 @@ func (sql SQLite) LoadMsgs(sessionID string) (string, error) {
 
 	if sql.record.Msgs != nil {
 		for _, msg := range sql.record.Msgs {
-			result = append(result, fmt.Sprintf("%s: %s", msg.Role, msg.Content))
+			content, err := sql.cipher.Decrypt(msg.Content)
+			if err != nil {
+				return "", err
+			}
+
+			result = append(result, fmt.Sprintf("%s: %s", msg.Role, content))
 		}
 	}
 
 	return strings.Join(result, "\n"), nil
 }
+
+// chatSession converts a stored session to its schema representation,
+// decrypting the messages.
+func (sql SQLite) chatSession(session Session) schema.ChatSession {
+	msgs := []schema.Msg{}
+	for _, msg := range session.Msgs {
+		msgs = append(msgs, schema.Msg{
+			ID:        msg.ID,
+			Role:      schema.EnumRole(msg.Role),
+			Content:   sql.decrypt(msg.Content),
+			Timestamp: msg.Timestamp,
+			Meta:      copyMeta(msg.Meta),
+		})
+	}
+
+	chat := schema.ChatSession{
+		ID:        session.SessionID,
+		ParentID:  session.ParentID,
+		Title:     session.Title,
+		Provider:  session.Provider,
+		Model:     session.ModelName,
+		Tags:      append([]string{}, session.Tags...),
+		Pinned:    session.Pinned,
+		Msgs:      msgs,
+		CreatedAt: session.CreatedAt.Unix(),
+		UpdatedAt: session.UpdatedAt.Unix(),
+	}
+
+	if session.DeletedAt.Valid {
+		chat.DeletedAt = session.DeletedAt.Time.Unix()
+	}
+
+	return chat
+}
+
+// msgID returns the ID of a message, or a new one for messages without.
+func msgID(id string) string {
+	if id != "" {
+		return id
+	}
+
+	return randstr.String(12)
+}
+
+func copyMeta(meta map[string]string) schema.MsgMeta {
+	if meta == nil {
+		return nil
+	}
+
+	copied := schema.MsgMeta{}
+	for key, value := range meta {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+// decrypt returns the plain text of the content, or a placeholder when it
+// can't be decrypted.
+func (sql SQLite) decrypt(content string) string {
+	plain, err := sql.cipher.Decrypt(content)
+	if err != nil {
+		return "[encrypted]"
+	}
+
+	return plain
+}
+
+func truncate(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) > max {
+		return string(runes[:max])
+	}
+
+	return text
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/struki84/clipt/tui/schema"
 	"github.com/thanhpk/randstr"
@@ -70,3 +71,367 @@ func TestLoadRecentSession(t *testing.T) {
 		}
 	}
 }
+
+func TestForkSession(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	parent := Session{
+		SessionID: randstr.String(8),
+		Title:     "Parent",
+		Msgs: Messages{
+			{Role: "UserMsg", Content: "first"},
+			{Role: "AIMsg", Content: "second"},
+			{Role: "UserMsg", Content: "third"},
+		},
+	}
+	err = sqliteDB.db.Create(&parent).Error
+	if err != nil {
+		t.Fatalf("Failed to create parent session: %v", err)
+	}
+
+	fork, err := sqliteDB.ForkSession(parent.SessionID, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if fork.ParentID != parent.SessionID {
+		t.Errorf("Expected parent ID %s, got %s", parent.SessionID, fork.ParentID)
+	}
+	if fork.Title != "Parent (fork)" {
+		t.Errorf("Expected title 'Parent (fork)', got %s", fork.Title)
+	}
+	if len(fork.Msgs) != 2 || fork.Msgs[1].Content != "second" {
+		t.Errorf("Expected 2 messages ending with 'second', got %v", fork.Msgs)
+	}
+
+	loaded, err := sqliteDB.LoadSession(fork.ID)
+	if err != nil {
+		t.Fatalf("Expected no error loading fork, got %v", err)
+	}
+	if loaded.ParentID != parent.SessionID || len(loaded.Msgs) != 2 {
+		t.Errorf("Fork was not persisted correctly: %+v", loaded)
+	}
+
+	whole, err := sqliteDB.ForkSession(parent.SessionID, -1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(whole.Msgs) != 3 {
+		t.Errorf("Expected 3 messages, got %d", len(whole.Msgs))
+	}
+
+	_, err = sqliteDB.ForkSession(parent.SessionID, 3)
+	if err == nil {
+		t.Error("Expected error forking past the last message")
+	}
+
+	_, err = sqliteDB.ForkSession("missing", 0)
+	if err == nil {
+		t.Error("Expected error forking a missing session")
+	}
+}
+
+func TestRenameSession(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	session, err := sqliteDB.NewSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	err = sqliteDB.RenameSession(session.ID, "Debugging the parser")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, err := sqliteDB.LoadSession(session.ID)
+	if err != nil {
+		t.Fatalf("Expected no error loading session, got %v", err)
+	}
+	if loaded.Title != "Debugging the parser" {
+		t.Errorf("Expected title 'Debugging the parser', got %s", loaded.Title)
+	}
+}
+
+func TestTrash(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	first, _ := sqliteDB.NewSession()
+	second, _ := sqliteDB.NewSession()
+
+	err = sqliteDB.DeleteSession(first.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(sqliteDB.ListSessions()) != 1 {
+		t.Errorf("Expected deleted session to be hidden from the list")
+	}
+
+	trash := sqliteDB.ListTrash()
+	if len(trash) != 1 || trash[0].ID != first.ID || trash[0].DeletedAt == 0 {
+		t.Fatalf("Expected deleted session in the trash, got %+v", trash)
+	}
+
+	err = sqliteDB.RestoreSession(first.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sqliteDB.ListSessions()) != 2 || len(sqliteDB.ListTrash()) != 0 {
+		t.Errorf("Expected session to be restored")
+	}
+
+	// Sessions deleted recently are kept by the retention period
+	sqliteDB.DeleteSession(first.ID)
+	sqliteDB.DeleteSession(second.ID)
+
+	count, err := sqliteDB.PurgeTrash(time.Hour)
+	if err != nil || count != 0 {
+		t.Errorf("Expected nothing purged within retention, got %d, %v", count, err)
+	}
+
+	err = sqliteDB.PurgeSession(first.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	count, err = sqliteDB.PurgeTrash(0)
+	if err != nil || count != 1 {
+		t.Errorf("Expected 1 session purged, got %d, %v", count, err)
+	}
+	if len(sqliteDB.ListTrash()) != 0 {
+		t.Errorf("Expected empty trash")
+	}
+}
+
+func TestSessionMetadata(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	first, _ := sqliteDB.NewSession()
+	second, _ := sqliteDB.NewSession()
+
+	err = sqliteDB.SetSessionProvider(first.ID, "openai/gpt-5.4", "openai/gpt-5.4")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = sqliteDB.SetSessionTags(first.ID, []string{"work", "go"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	err = sqliteDB.PinSession(first.ID, true)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, err := sqliteDB.LoadSession(first.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if loaded.Provider != "openai/gpt-5.4" || loaded.Model != "openai/gpt-5.4" {
+		t.Errorf("Expected provider and model to be restored, got %+v", loaded)
+	}
+	if len(loaded.Tags) != 2 || loaded.Tags[0] != "work" || !loaded.Pinned {
+		t.Errorf("Expected tags and pin to be restored, got %+v", loaded)
+	}
+	if loaded.UpdatedAt == 0 {
+		t.Error("Expected updated at to be set")
+	}
+
+	sessions := sqliteDB.ListSessions()
+	if len(sessions) != 2 || sessions[0].ID != first.ID || sessions[1].ID != second.ID {
+		t.Errorf("Expected pinned session listed first, got %+v", sessions)
+	}
+}
+
+func TestListSessionSummaries(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	for i := 0; i < 5; i++ {
+		session := Session{
+			SessionID: randstr.String(8),
+			Title:     "Session",
+			Msgs: Messages{
+				{Role: "UserMsg", Content: "question"},
+				{Role: "AIMsg", Content: "last answer"},
+			},
+		}
+		err = sqliteDB.db.Create(&session).Error
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+	}
+
+	fork, err := sqliteDB.ForkSession(sqliteDB.ListSessions()[0].ID, 0)
+	if err != nil {
+		t.Fatalf("Failed to fork session: %v", err)
+	}
+
+	page, err := sqliteDB.ListSessionSummaries(0, 4)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(page) != 4 {
+		t.Fatalf("Expected 4 summaries, got %d", len(page))
+	}
+
+	rest, err := sqliteDB.ListSessionSummaries(4, 4)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(rest) != 2 {
+		t.Fatalf("Expected 2 summaries on the last page, got %d", len(rest))
+	}
+
+	for _, summary := range append(page, rest...) {
+		if summary.ID == fork.ID {
+			if summary.MsgCount != 1 || summary.Snippet != "question" || summary.ParentTitle != "Session" {
+				t.Errorf("Unexpected fork summary: %+v", summary)
+			}
+			continue
+		}
+
+		if summary.MsgCount != 2 || summary.Snippet != "last answer" || summary.CreatedAt <= 0 {
+			t.Errorf("Unexpected summary: %+v", summary)
+		}
+	}
+}
+
+func TestAnnotateMsg(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	session, err := sqliteDB.NewSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	err = sqliteDB.SaveMsg(session.ID, schema.Msg{
+		Role:    schema.AIMsg,
+		Content: "hello",
+		Meta:    schema.MsgMeta{schema.MetaModel: "test-model", schema.MetaLatency: "1s"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to save message: %v", err)
+	}
+
+	loaded, err := sqliteDB.LoadSession(session.ID)
+	if err != nil {
+		t.Fatalf("Expected no error loading session, got %v", err)
+	}
+
+	msg := loaded.Msgs[0]
+	if msg.ID == "" {
+		t.Fatal("Expected the message to get an ID")
+	}
+
+	err = sqliteDB.AnnotateMsg(session.ID, msg.ID, schema.MsgMeta{schema.MetaRating: "good", schema.MetaLatency: ""})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	loaded, _ = sqliteDB.LoadSession(session.ID)
+	meta := loaded.Msgs[0].Meta
+	if loaded.Msgs[0].ID != msg.ID {
+		t.Errorf("Expected message ID %s to be kept, got %s", msg.ID, loaded.Msgs[0].ID)
+	}
+	if meta[schema.MetaRating] != "good" || meta[schema.MetaModel] != "test-model" {
+		t.Errorf("Expected rating and model in metadata, got %v", meta)
+	}
+	if _, ok := meta[schema.MetaLatency]; ok {
+		t.Errorf("Expected latency to be removed, got %v", meta)
+	}
+
+	err = sqliteDB.AnnotateMsg(session.ID, "missing", schema.MsgMeta{schema.MetaRating: "bad"})
+	if err == nil {
+		t.Error("Expected an error annotating a missing message")
+	}
+}
+
+func TestSaveTrashedSession(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "sqlite_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	sqliteDB := NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	session, _ := sqliteDB.NewSession()
+	sqliteDB.DeleteSession(session.ID)
+
+	session.Title = "Saved in the trash"
+	_, err = sqliteDB.SaveSession(session)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	trash := sqliteDB.ListTrash()
+	if len(sqliteDB.ListSessions()) != 0 || len(trash) != 1 || trash[0].Title != "Saved in the trash" {
+		t.Fatalf("Expected the session to be updated in the trash, got %+v", trash)
+	}
+
+	sqliteDB.RestoreSession(session.ID)
+
+	var count int64
+	sqliteDB.db.Unscoped().Model(&Session{}).Where("session_id = ?", session.ID).Count(&count)
+	if count != 1 || len(sqliteDB.ListSessions()) != 1 {
+		t.Fatalf("Expected one row for the restored session, got %d", count)
+	}
+}
@@ -0,0 +1,77 @@
+package templates
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type Template struct {
+	Name string
+	Path string
+	Body string
+	Vars []string
+}
+
+var varPattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Load reads every file in the directory as a template named after the
+// file, hidden files and subdirectories are skipped.
+func Load(dir string) ([]Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading templates: %v", err)
+	}
+
+	list := []Template{}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading template %s: %v", entry.Name(), err)
+		}
+
+		list = append(list, Parse(strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())), string(data)))
+		list[len(list)-1].Path = path
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+
+	return list, nil
+}
+
+// Parse creates a template from its text, collecting its variables in the
+// order they first appear.
+func Parse(name string, body string) Template {
+	template := Template{Name: name, Body: strings.TrimSpace(body), Vars: []string{}}
+
+	seen := map[string]bool{}
+	for _, match := range varPattern.FindAllStringSubmatch(template.Body, -1) {
+		if !seen[match[1]] {
+			seen[match[1]] = true
+			template.Vars = append(template.Vars, match[1])
+		}
+	}
+
+	return template
+}
+
+// Expand replaces the variables with their values, variables without a
+// value are left in place.
+func (template Template) Expand(values map[string]string) string {
+	return varPattern.ReplaceAllStringFunc(template.Body, func(match string) string {
+		name := varPattern.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value
+		}
+
+		return match
+	})
+}
@@ -0,0 +1,51 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseAndExpand(t *testing.T) {
+	template := Parse("review", "Review this {{ lang }} diff for {{focus}}:\n\n{{diff}}\n\nKeep {{focus}} in mind.")
+
+	if len(template.Vars) != 3 || template.Vars[0] != "lang" || template.Vars[1] != "focus" || template.Vars[2] != "diff" {
+		t.Fatalf("Expected vars [lang focus diff], got %v", template.Vars)
+	}
+
+	text := template.Expand(map[string]string{"lang": "Go", "focus": "races"})
+	expected := "Review this Go diff for races:\n\n{{diff}}\n\nKeep races in mind."
+	if text != expected {
+		t.Errorf("Expected %q, got %q", expected, text)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := os.MkdirTemp("", "templates_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.WriteFile(filepath.Join(dir, "tests.md"), []byte("Write tests for {{code}}\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "explain.txt"), []byte("Explain this"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), []byte("skip"), 0o644)
+	os.Mkdir(filepath.Join(dir, "drafts"), 0o755)
+
+	list, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(list) != 2 || list[0].Name != "explain" || list[1].Name != "tests" {
+		t.Fatalf("Expected templates explain and tests, got %+v", list)
+	}
+	if list[1].Body != "Write tests for {{code}}" || len(list[1].Vars) != 1 {
+		t.Errorf("Unexpected template %+v", list[1])
+	}
+
+	_, err = Load(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Error("Expected an error for a missing directory")
+	}
+}
@@ -0,0 +1,195 @@
+package tui
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/chat"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// EnterAction moves the focus from the input to the transcript, selecting
+// the last message.
+func (layout LayoutView) EnterAction() LayoutView {
+	layout.Mode = schema.Action
+	layout.Chat.Input.Blur()
+	layout.Chat = layout.Chat.Select(len(layout.Chat.Msgs) - 1)
+
+	return layout
+}
+
+// LeaveAction gives the focus back to the input.
+func (layout LayoutView) LeaveAction() (LayoutView, tea.Cmd) {
+	layout.Mode = schema.Chat
+	layout.Info = layout.ChatInfo()
+	layout.Chat.Raw = -1
+	layout.Chat = layout.Chat.Select(-1)
+
+	return layout, layout.Chat.Input.Focus()
+}
+
+// UpdateAction handles key presses in the action mode, the keys act on the
+// selected message.
+func (layout LayoutView) UpdateAction(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	selected := layout.Chat.Selected
+
+	switch msg.String() {
+	case "tab":
+		return layout.NextMode()
+	case "esc":
+		return layout.LeaveAction()
+	case "j", "down":
+		layout.Chat = layout.Chat.Select(selected + 1)
+	case "k", "up":
+		if selected > 0 {
+			layout.Chat = layout.Chat.Select(selected - 1)
+		}
+	case "g", "home":
+		layout.Chat = layout.Chat.Select(0)
+	case "G", "end":
+		layout.Chat = layout.Chat.Select(len(layout.Chat.Msgs) - 1)
+	case "pgup", "pgdown":
+		viewport, cmd := layout.Chat.Viewport.Update(msg)
+		*layout.Chat.Viewport = viewport
+		return layout, cmd
+	}
+
+	if selected < 0 || selected >= len(layout.Chat.Msgs) {
+		return layout, nil
+	}
+
+	message := layout.Chat.Msgs[selected]
+
+	switch msg.String() {
+	case "y":
+		return layout.CopyMsg(message.Content, "Message copied"), nil
+	case "c", "1", "2", "3", "4", "5", "6", "7", "8", "9":
+		number, _ := strconv.Atoi(msg.String())
+		return layout.CopyCodeBlock(message, max(number, 1)), nil
+	case "e":
+		if message.Role != schema.UserMsg {
+			return layout.AddMsg(schema.ErrMsg, "Only prompts can be edited"), nil
+		}
+
+		layout, cmd := layout.LeaveAction()
+		layout.Chat.Input.SetValue(message.Content)
+
+		return layout, cmd
+	case "d":
+		return layout.DeleteMsg(selected), nil
+	case "r":
+		return layout.Regenerate(selected)
+	case "v":
+		if layout.Chat.Raw == selected {
+			layout.Chat.Raw = -1
+		} else {
+			layout.Chat.Raw = selected
+		}
+
+		layout.Chat = layout.Chat.Select(selected)
+	case "i":
+		layout = layout.AddMsg(schema.InternalMsg, MsgInfo(message))
+		layout.Chat = layout.Chat.Select(selected)
+	}
+
+	return layout, nil
+}
+
+// CopyMsg copies the text to the clipboard and reports it in the transcript.
+func (layout LayoutView) CopyMsg(text string, note string) LayoutView {
+	selected := layout.Chat.Selected
+
+	err := layout.CopyText(text)
+	if err != nil {
+		log.Printf("Error while copying: %s", err)
+		layout = layout.AddMsg(schema.ErrMsg, err.Error())
+	} else {
+		layout = layout.AddMsg(schema.InternalMsg, note)
+	}
+
+	if layout.Mode == schema.Action {
+		layout.Chat = layout.Chat.Select(selected)
+	}
+
+	return layout
+}
+
+// CopyCodeBlock copies the numbered code block of the message.
+func (layout LayoutView) CopyCodeBlock(msg schema.Msg, number int) LayoutView {
+	blocks := chat.CodeBlocks(msg.Content)
+	if number > len(blocks) {
+		if len(blocks) == 0 {
+			return layout.AddMsg(schema.ErrMsg, "The message has no code blocks")
+		}
+
+		return layout.AddMsg(schema.ErrMsg, fmt.Sprintf("The message has %d code blocks", len(blocks)))
+	}
+
+	return layout.CopyMsg(blocks[number-1], fmt.Sprintf("Code block %d copied", number))
+}
+
+// DeleteMsg removes the message from the transcript and the storage.
+func (layout LayoutView) DeleteMsg(index int) LayoutView {
+	msg := layout.Chat.Msgs[index]
+
+	if msg.ID != "" && layout.Storage != nil {
+		storage, ok := layout.Storage.(schema.EditableStorage)
+		if !ok {
+			return layout.AddMsg(schema.ErrMsg, "The storage doesn't support deleting messages")
+		}
+
+		err := storage.DeleteMsg(layout.Chat.Session.ID, msg.ID)
+		if err != nil {
+			log.Printf("Error while deleting message: %s", err)
+			return layout.AddMsg(schema.ErrMsg, err.Error())
+		}
+	}
+
+	layout.Chat.Msgs = append(layout.Chat.Msgs[:index:index], layout.Chat.Msgs[index+1:]...)
+	layout.Chat.Raw = -1
+	layout.Chat = layout.Chat.Select(min(index, len(layout.Chat.Msgs)-1))
+
+	return layout
+}
+
+// Regenerate replaces the last answer by running its prompt again.
+func (layout LayoutView) Regenerate(index int) (tea.Model, tea.Cmd) {
+	if layout.Chat.IsLoading {
+		return layout, nil
+	}
+
+	last := -1
+	prompt := -1
+	for i, msg := range layout.Chat.Msgs {
+		switch msg.Role {
+		case schema.AIMsg:
+			last = i
+		case schema.UserMsg:
+			if last < i {
+				prompt = i
+			}
+		}
+	}
+
+	if index != last || prompt < 0 || prompt > last {
+		return layout.AddMsg(schema.ErrMsg, "Only the last answer can be regenerated"), nil
+	}
+
+	if _, ok := layout.Storage.(schema.EditableStorage); layout.Storage != nil && !ok {
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't support deleting messages"), nil
+	}
+
+	// The provider saves the prompt again with the new answer
+	input := layout.Chat.Msgs[prompt].Content
+	layout = layout.DeleteMsg(last)
+	layout = layout.DeleteMsg(prompt)
+
+	layout, _ = layout.LeaveAction()
+
+	var cmd tea.Cmd
+	layout.Chat, cmd = layout.Chat.Send(input)
+
+	return layout, tea.Batch(cmd, layout.Chat.Input.Focus())
+}
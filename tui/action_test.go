@@ -0,0 +1,110 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func pressAction(t *testing.T, layout LayoutView, key string) LayoutView {
+	model, _ := layout.UpdateAction(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return model.(LayoutView)
+}
+
+func TestActionSelection(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{2: "A note"})
+
+	layout = layout.EnterAction()
+	if layout.Chat.Selected != 4 {
+		t.Fatalf("Expected the last message selected, got %d", layout.Chat.Selected)
+	}
+
+	layout = pressAction(t, layout, "k")
+	layout = pressAction(t, layout, "k")
+	if layout.Chat.Selected != 2 {
+		t.Errorf("Expected the note selected, got %d", layout.Chat.Selected)
+	}
+
+	layout = pressAction(t, layout, "g")
+	layout = pressAction(t, layout, "k")
+	if layout.Chat.Selected != 0 {
+		t.Errorf("Expected the selection to stop at the first message, got %d", layout.Chat.Selected)
+	}
+
+	layout = pressAction(t, layout, "G")
+	if layout.Chat.Selected != 4 {
+		t.Errorf("Expected the last message selected, got %d", layout.Chat.Selected)
+	}
+}
+
+func TestActionDelete(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{2: "A note"})
+
+	// The second question is at index 3 behind the note
+	layout = layout.EnterAction()
+	layout.Chat = layout.Chat.Select(3)
+	layout = pressAction(t, layout, "d")
+
+	stored, err := sqliteDB.LoadSession(layout.Chat.Session.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+
+	for _, msg := range stored.Msgs {
+		if msg.Content == "second question" {
+			t.Errorf("Expected the second question deleted, got %v", stored.Msgs)
+		}
+	}
+
+	if len(stored.Msgs) != 3 || len(layout.Chat.Msgs) != 4 || layout.Chat.Msgs[2].Content != "A note" {
+		t.Errorf("Expected only the second question removed, got %v", layout.Chat.Msgs)
+	}
+
+	// Notes are only removed from the transcript
+	layout.Chat = layout.Chat.Select(2)
+	layout = pressAction(t, layout, "d")
+
+	if len(layout.Chat.Msgs) != 3 {
+		t.Errorf("Expected the note removed, got %v", layout.Chat.Msgs)
+	}
+}
+
+func TestActionRegenerate(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{3: "A note"})
+
+	layout = layout.EnterAction()
+	layout.Chat = layout.Chat.Select(1)
+	layout = pressAction(t, layout, "r")
+
+	last := layout.Chat.Msgs[len(layout.Chat.Msgs)-1]
+	if last.Role != schema.ErrMsg {
+		t.Errorf("Expected only the last answer to be regenerated, got %v", last)
+	}
+
+	layout.Mode = schema.Action
+	layout.Chat = layout.Chat.Select(4)
+	layout = pressAction(t, layout, "r")
+
+	if layout.Mode != schema.Chat || !layout.Chat.IsLoading {
+		t.Fatal("Expected the prompt to be sent again")
+	}
+
+	msgs := layout.Chat.Msgs
+	prompt := msgs[len(msgs)-2]
+	if prompt.Role != schema.UserMsg || prompt.Content != "second question" {
+		t.Errorf("Expected the second question sent again, got %v", msgs)
+	}
+
+	stored, err := sqliteDB.LoadSession(layout.Chat.Session.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+
+	if len(stored.Msgs) != 2 {
+		t.Errorf("Expected the last exchange removed from storage, got %v", stored.Msgs)
+	}
+}
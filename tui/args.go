@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// ExecuteCmd runs the command picked in the menu. Commands taking arguments
+// get them parsed from the prompt, when required ones are missing the
+// prompt is kept for typing them and the usage is shown.
+func (layout LayoutView) ExecuteCmd(cmd schema.CmdItem) (tea.Model, tea.Cmd) {
+	argsCmd, ok := cmd.(schema.ArgsCmd)
+	if !ok {
+		return syncInfo(cmd.Execute(layout))
+	}
+
+	prompt := layout.Chat.Input.Value()
+	args := schema.ParseArgs(prompt)
+
+	// The command was picked by a part of its name
+	if args.Name != cmd.Title() {
+		prompt = cmd.Title()
+		args = schema.ParseArgs(prompt)
+	}
+
+	if args.Len() < schema.RequiredArgs(argsCmd.Usage()) {
+		if !strings.HasSuffix(prompt, " ") {
+			prompt += " "
+		}
+
+		layout.Chat.Input.SetValue(prompt)
+
+		return layout.SyncMenu(), nil
+	}
+
+	return syncInfo(argsCmd.ExecuteArgs(layout, args))
+}
+
+// syncInfo drops the menu keys and usage from the info line once a command
+// closed the menu.
+func syncInfo(model tea.Model, cmd tea.Cmd) (tea.Model, tea.Cmd) {
+	if layout, ok := model.(LayoutView); ok && !layout.Menu.Active {
+		layout.Info = layout.ChatInfo()
+		return layout, cmd
+	}
+
+	return model, cmd
+}
+
+// SyncMenu follows the prompt with the menu: the matching commands, the
+// usage of a command taking arguments and the values suggested for them.
+func (layout LayoutView) SyncMenu() LayoutView {
+	prompt := layout.Chat.Input.Value()
+
+	active := layout.Menu.Active
+
+	layout.Menu.Active = strings.HasPrefix(prompt, "/")
+	if !layout.Menu.Active {
+		if active {
+			layout.Info = layout.ChatInfo()
+		}
+
+		return layout
+	}
+
+	layout.Info = "ctrl+j - down | ctrl+k - up"
+	layout.Menu.SearchString = strings.TrimPrefix(prompt, "/")
+	layout.Menu = layout.Menu.Filter()
+
+	args := schema.ParseArgs(prompt)
+	cmd, ok := layout.Menu.Command(args.Name).(schema.ArgsCmd)
+	if !ok || cmd.Usage() == "" || !strings.Contains(prompt, " ") {
+		return layout
+	}
+
+	layout.Info = "usage: " + cmd.Usage()
+
+	completer, ok := cmd.(schema.Completer)
+	if !ok {
+		return layout
+	}
+
+	items := []list.Item{}
+	for _, value := range completer.Complete(layout, args) {
+		items = append(items, CompletionItem{cmd: cmd, args: args, value: value})
+	}
+
+	if menu, ok := layout.Menu.Suggest(items, args.Partial); ok {
+		layout.Menu = menu
+		layout.Info += " | tab - complete"
+	}
+
+	return layout
+}
+
+// cmdArgs parses the arguments of a command from the prompt.
+func cmdArgs(model tea.Model) schema.Args {
+	return schema.ParseArgs(model.(LayoutView).Chat.Input.Value())
+}
+
+// CompletionItem is a value suggested for the argument being typed, it
+// completes the prompt and runs the command.
+type CompletionItem struct {
+	cmd   schema.ArgsCmd
+	args  schema.Args
+	value string
+}
+
+func (item CompletionItem) Title() string       { return snippet(item.value, 28) }
+func (item CompletionItem) Description() string { return item.cmd.Usage() }
+func (item CompletionItem) FilterValue() string { return item.value }
+func (item CompletionItem) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Used(item.cmd)
+	layout.Chat.Input.SetValue(item.args.Complete(item.value))
+
+	return layout.ExecuteCmd(item.cmd)
+}
+
+// Prompt is the prompt completed with the value, ready for the next
+// argument.
+func (item CompletionItem) Prompt() string {
+	return item.args.Complete(item.value) + " "
+}
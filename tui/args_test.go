@@ -0,0 +1,150 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// suggested returns the values the menu suggests for the argument.
+func suggested(layout LayoutView) []string {
+	if !layout.Menu.Suggesting {
+		return nil
+	}
+
+	values := []string{}
+	for _, item := range layout.Menu.FilteredItems {
+		values = append(values, item.(CompletionItem).value)
+	}
+
+	return values
+}
+
+func typePrompt(layout LayoutView, prompt string) LayoutView {
+	layout.Chat.Input.SetValue(prompt)
+	return layout.SyncMenu()
+}
+
+func TestSyncMenuUsage(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	layout = typePrompt(layout, "/rena")
+	if layout.Info != "ctrl+j - down | ctrl+k - up" || layout.Menu.Suggesting {
+		t.Fatalf("Expected the commands while typing the name, got info %q", layout.Info)
+	}
+
+	layout = typePrompt(layout, "/rename ")
+	if layout.Info != "usage: /rename <title> | tab - complete" {
+		t.Fatalf("Expected the usage of /rename, got %q", layout.Info)
+	}
+
+	if values := suggested(layout); !slices.Equal(values, []string{"Test session"}) {
+		t.Fatalf("Expected the current title to be suggested, got %q", values)
+	}
+
+	// Nothing is suggested past the title
+	layout = typePrompt(layout, "/rename Test ")
+	if layout.Info != "usage: /rename <title>" || layout.Menu.Suggesting {
+		t.Fatalf("Expected only the usage, got %q", layout.Info)
+	}
+
+	layout = typePrompt(layout, "hello")
+	if layout.Menu.Active || layout.Info != layout.ChatInfo() {
+		t.Fatalf("Expected the menu to close, got info %q", layout.Info)
+	}
+}
+
+func TestSyncMenuCompletion(t *testing.T) {
+	layout, _ := newTestLayout(t)
+
+	tests := map[string][]string{
+		"/export ":      {"md", "json", "html"},
+		"/export j":     {"json"},
+		"/export json ": {"clipboard"},
+		"/export x":     nil,
+	}
+
+	for prompt, values := range tests {
+		got := suggested(typePrompt(layout, prompt))
+		if len(values) == 0 && len(got) == 0 {
+			continue
+		}
+
+		slices.Sort(got)
+		slices.Sort(values)
+		if !slices.Equal(got, values) {
+			t.Errorf("Expected %q to suggest %q, got %q", prompt, values, got)
+		}
+	}
+
+	item := CompletionItem{cmd: ExportCmd{title: "/export"}, args: schema.ParseArgs("/export j"), value: "json"}
+	if item.Prompt() != "/export json " {
+		t.Fatalf("Expected the completed prompt, got %q", item.Prompt())
+	}
+}
+
+func TestExecuteCmdMissingArgs(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	layout.Chat.Input.SetValue("/ren")
+	model, _ := layout.ExecuteCmd(RenameCmd{title: "/rename"})
+	layout = model.(LayoutView)
+
+	if layout.Chat.Input.Value() != "/rename " {
+		t.Fatalf("Expected the prompt to be kept for the title, got %q", layout.Chat.Input.Value())
+	}
+
+	if !layout.Menu.Active || layout.Chat.Session.Title != "Test session" {
+		t.Fatalf("Expected the menu to stay open and the session unchanged")
+	}
+}
+
+func TestRenameCmd(t *testing.T) {
+	tests := map[string]string{
+		"/rename Bob's notes":     "Bob's notes",
+		`/rename "Bob's notes"`:   "Bob's notes",
+		`/rename Notes on "this"`: `Notes on "this"`,
+	}
+
+	for prompt, title := range tests {
+		layout, sqliteDB := newTestLayout(t)
+		layout = openTestSession(t, layout, testMsgs(), nil)
+
+		layout = typePrompt(layout, prompt)
+		model, _ := layout.ExecuteCmd(RenameCmd{title: "/rename"})
+		layout = model.(LayoutView)
+
+		if layout.Chat.Session.Title != title {
+			t.Errorf("Expected %q to rename the session to %q, got %q", prompt, title, layout.Chat.Session.Title)
+		}
+
+		session, err := sqliteDB.LoadSession(layout.Chat.Session.ID)
+		if err != nil {
+			t.Fatalf("Failed to load session: %v", err)
+		}
+
+		if session.Title != title {
+			t.Errorf("Expected the stored title to be %q, got %q", title, session.Title)
+		}
+
+		if layout.Menu.Active || layout.Chat.Input.Value() != "" || layout.Info != layout.ChatInfo() {
+			t.Errorf("Expected the menu to close after renaming")
+		}
+	}
+}
+
+func TestRenameCmdWithoutTitle(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	layout.Chat.Input.SetValue("/rename  ")
+	model, _ := RenameCmd{title: "/rename"}.Execute(layout)
+	layout = model.(LayoutView)
+
+	if layout.Chat.Session.Title != "Test session" || lastMsg(layout).Content != "usage: /rename <title>" {
+		t.Fatalf("Expected the usage instead of an empty title, got %q", layout.Chat.Session.Title)
+	}
+}
@@ -0,0 +1,78 @@
+package chat
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CodeBlocks returns the contents of the fenced code blocks in the
+// markdown, in order.
+func CodeBlocks(markdown string) []string {
+	blocks := []string{}
+	lines := []string{}
+	fence := ""
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" {
+			fence = openingFence(trimmed)
+			lines = []string{}
+			continue
+		}
+
+		if closesFence(trimmed, fence) {
+			blocks = append(blocks, strings.Join(lines, "\n"))
+			fence = ""
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	// An unterminated block still counts, e.g. while it's being streamed
+	if fence != "" {
+		blocks = append(blocks, strings.Join(lines, "\n"))
+	}
+
+	return blocks
+}
+
+// NumberCodeBlocks labels every fenced code block in the markdown with its
+// number, the one used to copy it.
+func NumberCodeBlocks(markdown string) string {
+	lines := []string{}
+	fence := ""
+	number := 0
+
+	for _, line := range strings.Split(markdown, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if fence == "" {
+			fence = openingFence(trimmed)
+			if fence != "" {
+				number++
+				indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+				lines = append(lines, fmt.Sprintf("%s`[%d]`", indent, number))
+			}
+		} else if closesFence(trimmed, fence) {
+			fence = ""
+		}
+
+		lines = append(lines, line)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func openingFence(line string) string {
+	if strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~") {
+		return line[:3]
+	}
+
+	return ""
+}
+
+func closesFence(line string, fence string) bool {
+	return strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == ""
+}
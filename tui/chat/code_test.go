@@ -0,0 +1,28 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCodeBlocks(t *testing.T) {
+	markdown := "Intro\n\n```go\nfmt.Println(\"a\")\n```\n\n- item\n  ~~~\n  b\n  ~~~\n\n```sh\necho c"
+
+	blocks := CodeBlocks(markdown)
+	if len(blocks) != 3 {
+		t.Fatalf("Expected 3 code blocks, got %d: %q", len(blocks), blocks)
+	}
+
+	if blocks[0] != "fmt.Println(\"a\")" || blocks[1] != "  b" || blocks[2] != "echo c" {
+		t.Errorf("Unexpected code blocks: %q", blocks)
+	}
+
+	numbered := NumberCodeBlocks(markdown)
+	if !strings.Contains(numbered, "`[1]`\n```go") || !strings.Contains(numbered, "  `[2]`\n  ~~~") || !strings.Contains(numbered, "`[3]`\n```sh") {
+		t.Errorf("Expected numbered code blocks, got:\n%s", numbered)
+	}
+
+	if strings.Count(numbered, "`[") != 3 {
+		t.Errorf("Expected only opening fences to be numbered, got:\n%s", numbered)
+	}
+}
@@ -0,0 +1,87 @@
+package chat
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// EditorMsg carries the prompt edited in the external editor.
+type EditorMsg struct {
+	Content string
+	Err     error
+}
+
+// Editor returns the command line of the user's editor from $VISUAL or
+// $EDITOR, falling back to vi.
+func Editor() []string {
+	for _, name := range []string{"VISUAL", "EDITOR"} {
+		if fields := strings.Fields(os.Getenv(name)); len(fields) > 0 {
+			return fields
+		}
+	}
+
+	return []string{"vi"}
+}
+
+// OpenEditor suspends the program and opens the current input in the
+// external editor, the edited text comes back as an EditorMsg.
+func (chat ChatView) OpenEditor() tea.Cmd {
+	file, err := os.CreateTemp("", "clipt-*.md")
+	if err != nil {
+		return func() tea.Msg {
+			return EditorMsg{Err: fmt.Errorf("Error creating prompt file: %v", err)}
+		}
+	}
+
+	_, err = file.WriteString(chat.Input.Value())
+	file.Close()
+	if err != nil {
+		os.Remove(file.Name())
+		return func() tea.Msg {
+			return EditorMsg{Err: fmt.Errorf("Error writing prompt file: %v", err)}
+		}
+	}
+
+	editor := Editor()
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(file.Name())
+
+		if err != nil {
+			return EditorMsg{Err: fmt.Errorf("Error running editor: %v", err)}
+		}
+
+		data, err := os.ReadFile(file.Name())
+		if err != nil {
+			return EditorMsg{Err: fmt.Errorf("Error reading prompt file: %v", err)}
+		}
+
+		return EditorMsg{Content: strings.TrimRight(string(data), "\n")}
+	})
+}
+
+// HandleEditor loads the edited prompt into the input, or sends it right
+// away when EditorSend is set.
+func (chat ChatView) HandleEditor(msg EditorMsg) (ChatView, tea.Cmd) {
+	if msg.Err != nil {
+		chat.Msgs = append(chat.Msgs, schema.Msg{Role: schema.ErrMsg, Content: msg.Err.Error()})
+		chat.Viewport.SetContent(chat.RenderMsgs())
+		chat.Viewport.GotoBottom()
+
+		return chat, nil
+	}
+
+	if chat.EditorSend && strings.TrimSpace(msg.Content) != "" && !chat.IsLoading {
+		return chat.Send(msg.Content)
+	}
+
+	chat.Input.SetValue(msg.Content)
+
+	return chat, nil
+}
@@ -0,0 +1,43 @@
+package chat
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestHandleEditor(t *testing.T) {
+	tests := []struct {
+		name    string
+		send    bool
+		loading bool
+		msg     EditorMsg
+		input   string
+		msgs    int
+	}{
+		{name: "error", msg: EditorMsg{Err: errors.New("Error running editor: exit status 1")}, input: "draft", msgs: 1},
+		{name: "unchanged", msg: EditorMsg{Content: "draft"}, input: "draft"},
+		{name: "new content", msg: EditorMsg{Content: "a longer\nprompt"}, input: "a longer\nprompt"},
+		{name: "send", send: true, msg: EditorMsg{Content: "a prompt"}, input: "", msgs: 2},
+		{name: "send empty", send: true, msg: EditorMsg{Content: "  \n"}, input: "  \n"},
+		{name: "send while loading", send: true, loading: true, msg: EditorMsg{Content: "a prompt"}, input: "a prompt"},
+	}
+
+	for _, test := range tests {
+		chat := newTestChat()
+		chat.EditorSend = test.send
+		chat.IsLoading = test.loading
+		chat.Input.SetValue("draft")
+
+		chat, _ = chat.HandleEditor(test.msg)
+
+		if chat.Input.Value() != test.input || len(chat.Msgs) != test.msgs {
+			t.Errorf("%s: expected input %q and %d messages, got %q and %v", test.name, test.input, test.msgs, chat.Input.Value(), chat.Msgs)
+		}
+
+		if test.msg.Err != nil && (chat.Msgs[0].Role != schema.ErrMsg || chat.Msgs[0].Content != test.msg.Err.Error()) {
+			t.Errorf("%s: expected the error in the transcript, got %v", test.name, chat.Msgs)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package chat
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// Find is the state of a search in the transcript. While Editing the keys
+// change the query, after that n and N jump between the matches.
+type Find struct {
+	Open    bool
+	Editing bool
+	Query   string
+	Current int
+	matches *[]FindMatch
+}
+
+// FindMatch is an occurrence of the query in the rendered transcript, the
+// start and end are cells of the line.
+type FindMatch struct {
+	Line  int
+	Start int
+	End   int
+}
+
+// Matches returns the matches of the last rendering.
+func (find Find) Matches() []FindMatch {
+	if find.matches == nil {
+		return nil
+	}
+
+	return *find.matches
+}
+
+// OpenFind starts a search, with a query it jumps to the first match in or
+// below the viewport.
+func (chat ChatView) OpenFind(query string) ChatView {
+	chat.Find = Find{Open: true, Editing: query == "", Query: query, matches: &[]FindMatch{}}
+	chat.Viewport.SetContent(chat.RenderMsgs())
+
+	matches := chat.Find.Matches()
+	if len(matches) == 0 {
+		return chat
+	}
+
+	chat.Find.Current = len(matches) - 1
+	for i, match := range matches {
+		if match.Line >= chat.Viewport.YOffset {
+			chat.Find.Current = i
+			break
+		}
+	}
+
+	return chat.FindNext(0)
+}
+
+// CloseFind ends the search and removes the highlighting.
+func (chat ChatView) CloseFind() ChatView {
+	chat.Find = Find{}
+	chat.Viewport.SetContent(chat.RenderMsgs())
+
+	return chat
+}
+
+// FindNext moves the current match by step, wrapping around, and scrolls
+// the transcript to it.
+func (chat ChatView) FindNext(step int) ChatView {
+	count := len(chat.Find.Matches())
+	if count == 0 {
+		return chat
+	}
+
+	chat.Find.Current = ((chat.Find.Current+step)%count + count) % count
+	chat.Viewport.SetContent(chat.RenderMsgs())
+
+	line := chat.Find.Matches()[chat.Find.Current].Line
+	if line < chat.Viewport.YOffset || line >= chat.Viewport.YOffset+chat.Viewport.Height {
+		chat.Viewport.SetYOffset(max(line-chat.Viewport.Height/2, 0))
+	}
+
+	return chat
+}
+
+// UpdateFind handles the keys of an open search, it reports false for the
+// keys it leaves to the input.
+func (chat ChatView) UpdateFind(msg tea.KeyMsg) (ChatView, bool) {
+	if chat.Find.Editing {
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlG:
+			return chat.CloseFind(), true
+		case tea.KeyEnter:
+			if chat.Find.Query == "" {
+				return chat.CloseFind(), true
+			}
+
+			chat.Find.Editing = false
+		case tea.KeyBackspace:
+			runes := []rune(chat.Find.Query)
+			if len(runes) > 0 {
+				chat.Find.Query = string(runes[:len(runes)-1])
+			}
+
+			chat = chat.OpenFind(chat.Find.Query)
+			chat.Find.Editing = true
+		case tea.KeyRunes, tea.KeySpace:
+			chat = chat.OpenFind(chat.Find.Query + string(msg.Runes))
+			chat.Find.Editing = true
+		}
+
+		return chat, true
+	}
+
+	switch msg.String() {
+	case "n":
+		return chat.FindNext(1), true
+	case "N":
+		return chat.FindNext(-1), true
+	case "ctrl+f":
+		chat.Find.Editing = true
+		return chat, true
+	case "esc", "enter":
+		return chat.CloseFind(), true
+	case "pgup", "pgdown":
+		return chat, false
+	}
+
+	// Typing goes back to the prompt
+	return chat.CloseFind(), false
+}
+
+// highlight marks the matches of the query in the rendered transcript and
+// records where they are.
+func (chat ChatView) highlight(content string) string {
+	matches := []FindMatch{}
+	query := strings.ToLower(chat.Find.Query)
+
+	if chat.Find.Open && query != "" {
+		lines := strings.Split(content, "\n")
+
+		for i, line := range lines {
+			plain := strings.ToLower(ansi.Strip(line))
+			ranges := []lipgloss.Range{}
+
+			for from := 0; ; {
+				index := strings.Index(plain[from:], query)
+				if index < 0 {
+					break
+				}
+
+				start := from + index
+				from = start + len(query)
+
+				match := FindMatch{Line: i, Start: ansi.StringWidth(plain[:start]), End: ansi.StringWidth(plain[:from])}
+
+				style := chat.Style.Chat.Match
+				if len(matches) == chat.Find.Current {
+					style = chat.Style.Chat.CurrentMatch
+				}
+
+				ranges = append(ranges, lipgloss.NewRange(match.Start, match.End, style))
+				matches = append(matches, match)
+			}
+
+			lines[i] = lipgloss.StyleRanges(line, ranges...)
+		}
+
+		content = strings.Join(lines, "\n")
+	}
+
+	if chat.Find.matches != nil {
+		*chat.Find.matches = matches
+	}
+
+	return content
+}
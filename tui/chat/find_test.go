@@ -0,0 +1,34 @@
+package chat
+
+import (
+	"testing"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestHighlight(t *testing.T) {
+	chat := ChatView{Find: Find{Open: true, Query: "foo", Current: 1, matches: &[]FindMatch{}}}
+	content := "a foo b\n\x1b[1mFOO\x1b[0m │ foo"
+
+	highlighted := chat.highlight(content)
+	if ansi.Strip(highlighted) != ansi.Strip(content) {
+		t.Errorf("Expected the text to be kept, got %q", ansi.Strip(highlighted))
+	}
+
+	expected := []FindMatch{{Line: 0, Start: 2, End: 5}, {Line: 1, Start: 0, End: 3}, {Line: 1, Start: 6, End: 9}}
+	matches := chat.Find.Matches()
+	if len(matches) != len(expected) {
+		t.Fatalf("Expected %d matches, got %v", len(expected), matches)
+	}
+
+	for i, match := range matches {
+		if match != expected[i] {
+			t.Errorf("Expected match %d to be %v, got %v", i, expected[i], match)
+		}
+	}
+
+	chat.Find = Find{}
+	if chat.highlight(content) != content || len(chat.Find.Matches()) != 0 {
+		t.Errorf("Expected no highlighting without a search")
+	}
+}
@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"bufio"
+	"encoding/json"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// History keeps the prompts sent from the input, most recent last. With a
+// path set it's loaded from and saved to that file as JSON lines.
+type History struct {
+	Entries []string
+	Size    int
+	Path    string
+
+	// pos is the entry being browsed, len(Entries) while editing a draft
+	pos   int
+	draft string
+}
+
+// HistorySearch is the state of a reverse incremental search.
+type HistorySearch struct {
+	Active bool
+	Query  string
+	Match  int
+	draft  string
+}
+
+func NewHistory(path string, size int) *History {
+	history := &History{Entries: []string{}, Size: size, Path: path}
+	if path == "" {
+		return history
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("Error loading history: %v", err)
+		}
+
+		return history
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		entry := ""
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry != "" {
+			history.Entries = append(history.Entries, entry)
+		}
+	}
+
+	history.trim()
+	history.pos = len(history.Entries)
+
+	return history
+}
+
+// Add records a sent prompt, repeating the previous one isn't recorded.
+func (history *History) Add(entry string) {
+	history.pos = len(history.Entries)
+	history.draft = ""
+
+	if strings.TrimSpace(entry) == "" {
+		return
+	}
+
+	if len(history.Entries) > 0 && history.Entries[len(history.Entries)-1] == entry {
+		return
+	}
+
+	history.Entries = append(history.Entries, entry)
+	trimmed := history.trim()
+	history.pos = len(history.Entries)
+
+	history.save(entry, trimmed)
+}
+
+// Prev returns the entry before the one being browsed, the current input
+// is kept as a draft to come back to.
+func (history *History) Prev(current string) (string, bool) {
+	if history.pos == 0 || len(history.Entries) == 0 {
+		return "", false
+	}
+
+	if history.pos == len(history.Entries) {
+		history.draft = current
+	}
+
+	history.pos--
+
+	return history.Entries[history.pos], true
+}
+
+// Next returns the entry after the one being browsed, or the draft.
+func (history *History) Next() (string, bool) {
+	if history.pos >= len(history.Entries) {
+		return "", false
+	}
+
+	history.pos++
+	if history.pos == len(history.Entries) {
+		return history.draft, true
+	}
+
+	return history.Entries[history.pos], true
+}
+
+// Search returns the most recent entry containing the query, starting at
+// the entry before index.
+func (history *History) Search(query string, index int) (int, bool) {
+	if index > len(history.Entries) {
+		index = len(history.Entries)
+	}
+
+	query = strings.ToLower(query)
+	for i := index - 1; i >= 0; i-- {
+		if strings.Contains(strings.ToLower(history.Entries[i]), query) {
+			return i, true
+		}
+	}
+
+	return -1, false
+}
+
+func (history *History) trim() bool {
+	if history.Size <= 0 || len(history.Entries) <= history.Size {
+		return false
+	}
+
+	history.Entries = history.Entries[len(history.Entries)-history.Size:]
+
+	return true
+}
+
+// save appends the entry to the history file, or rewrites the file when
+// old entries were dropped.
+func (history *History) save(entry string, rewrite bool) {
+	if history.Path == "" {
+		return
+	}
+
+	err := os.MkdirAll(filepath.Dir(history.Path), 0o700)
+	if err != nil {
+		log.Printf("Error saving history: %v", err)
+		return
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	entries := []string{entry}
+	if rewrite {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+		entries = history.Entries
+	}
+
+	file, err := os.OpenFile(history.Path, flags, 0o600)
+	if err != nil {
+		log.Printf("Error saving history: %v", err)
+		return
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, entry := range entries {
+		line, _ := json.Marshal(entry)
+		writer.Write(append(line, '\n'))
+	}
+
+	err = writer.Flush()
+	if err != nil {
+		log.Printf("Error saving history: %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package chat
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistory(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "history_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	path := filepath.Join(tempDir, "history")
+	history := NewHistory(path, 3)
+
+	for _, entry := range []string{"first", "second\nline", "second\nline", "third", "fourth"} {
+		history.Add(entry)
+	}
+
+	if len(history.Entries) != 3 || history.Entries[0] != "second\nline" {
+		t.Fatalf("Expected the 3 most recent distinct entries, got %q", history.Entries)
+	}
+
+	entry, _ := history.Prev("draft")
+	entry, _ = history.Prev(entry)
+	if entry != "third" {
+		t.Errorf("Expected 'third', got %q", entry)
+	}
+
+	history.Next()
+	entry, _ = history.Next()
+	if entry != "draft" {
+		t.Errorf("Expected the draft back, got %q", entry)
+	}
+
+	match, ok := history.Search("LINE", len(history.Entries))
+	if !ok || match != 0 {
+		t.Errorf("Expected a match at 0, got %d, %v", match, ok)
+	}
+
+	reloaded := NewHistory(path, 3)
+	if len(reloaded.Entries) != 3 || reloaded.Entries[2] != "fourth" || reloaded.Entries[0] != "second\nline" {
+		t.Errorf("Expected the history to be persisted, got %q", reloaded.Entries)
+	}
+}
@@ -25,11 +25,31 @@ type ChatView struct {
 	Style    schema.LayoutStyle
 	Msgs     []schema.Msg
 	Stream   chan schema.Msg
+	Events   chan schema.DebugEvent
 	Provider schema.ChatProvider
 	Session  schema.ChatSession
 
 	IsLoading bool
 
+	// Selected is the message selected in the action mode and Raw the one
+	// shown as raw markdown, -1 for none.
+	Selected int
+	Raw      int
+	offsets  *[]int
+
+	// Keys are the send and newline bindings, the input grows with its
+	// content up to MaxInputHeight rows and scrolls after that.
+	Keys           KeyMap
+	MaxInputHeight int
+
+	// EditorSend sends the prompt written in the external editor instead
+	// of loading it into the input.
+	EditorSend bool
+
+	History *History
+	Search  HistorySearch
+	Find    Find
+
 	Header   string
 	Viewport *viewport.Model
 	Input    *textarea.Model
@@ -41,6 +61,9 @@ func New(provider schema.ChatProvider, style schema.LayoutStyle) ChatView {
 	input.Focus()
 	input.CharLimit = 0
 	input.KeyMap.InsertNewline.SetEnabled(false)
+	input.MaxHeight = 0
+	input.KeyMap.LineEnd = key.NewBinding(key.WithKeys("end"))
+	input.KeyMap.CharacterForward = key.NewBinding(key.WithKeys("right"))
 
 	view := viewport.New(0, 0)
 	loader := spinner.New()
@@ -54,6 +77,14 @@ func New(provider schema.ChatProvider, style schema.LayoutStyle) ChatView {
 		Loader:    loader,
 		IsLoading: false,
 		Stream:    make(chan schema.Msg),
+		Events:    make(chan schema.DebugEvent, 256),
+		History:   NewHistory("", 0),
+		Keys:      DefaultKeyMap(),
+		Selected:  -1,
+		Raw:       -1,
+		offsets:   &[]int{},
+
+		MaxInputHeight: 10,
 	}
 }
 
@@ -61,13 +92,33 @@ func (chat ChatView) Init() tea.Cmd {
 	cmds := []tea.Cmd{}
 	cmds = append(cmds, textarea.Blink)
 	cmds = append(cmds, chat.HandleStream)
+	cmds = append(cmds, chat.HandleDebug)
+
+	chat.Connect(chat.Provider)
+
+	return tea.Batch(cmds...)
+}
+
+// Connect routes the stream and, when supported, the debug events of the
+// provider into the chat.
+func (chat ChatView) Connect(provider schema.ChatProvider) {
+	stream := chat.Stream
+	events := chat.Events
 
-	chat.Provider.Stream(context.TODO(), func(ctx context.Context, msg schema.Msg) error {
-		chat.Stream <- msg
+	provider.Stream(context.TODO(), func(ctx context.Context, msg schema.Msg) error {
+		stream <- msg
 		return nil
 	})
 
-	return tea.Batch(cmds...)
+	if debug, ok := provider.(schema.DebugProvider); ok {
+		debug.Debug(func(event schema.DebugEvent) {
+			// Events are dropped rather than slow down the provider
+			select {
+			case events <- event:
+			default:
+			}
+		})
+	}
 }
 
 func (chat ChatView) View() string {
@@ -89,8 +140,8 @@ func (chat ChatView) View() string {
 	}
 
 	chat.Input.Prompt = ""
-	chat.Input.SetHeight(chat.Input.LineInfo().Height + 1)
 	chat.Input.SetWidth(chat.WindowSize.Width - 4)
+	chat.Input.SetHeight(chat.InputHeight())
 	chat.Input.FocusedStyle.CursorLine = lipgloss.NewStyle()
 	chat.Input.FocusedStyle.Base = chat.Style.Chat.Input
 	chat.Input.ShowLineNumbers = false
@@ -107,52 +158,98 @@ func (chat ChatView) RenderMsgs() string {
 	}
 
 	width := chat.Viewport.Width - 6
+	offsets := []int{}
+	offset := 0
+
+	for i, msg := range chat.Msgs {
+		// The selected message is marked with a border, its content shrinks
+		// to keep the width
+		msgWidth := width
+		if i == chat.Selected {
+			msgWidth--
+		}
+
+		chatMsg := ""
 
-	for _, msg := range chat.Msgs {
 		switch msg.Role {
 		case schema.InternalMsg:
 			fullMsg := fmt.Sprintf("%s", msg.Content)
-			chatMsg := chat.Style.Chat.Msg.Internal.Width(width).Render(fullMsg)
-
-			styledMessages = append(styledMessages, chatMsg)
+			chatMsg = chat.Style.Chat.Msg.Internal.Width(msgWidth).Render(fullMsg)
 		case schema.ErrMsg:
 			fullMsg := fmt.Sprintf("%s", msg.Content)
-			chatMsg := chat.Style.Chat.Msg.Err.Width(width).Render(fullMsg)
-
-			styledMessages = append(styledMessages, chatMsg)
+			chatMsg = chat.Style.Chat.Msg.Err.Width(msgWidth).Render(fullMsg)
 		case schema.SysMsg:
 			fullMsg := fmt.Sprintf("%s", msg.Content)
-			chatMsg := chat.Style.Chat.Msg.Sys.Width(width).Render(fullMsg)
-
-			styledMessages = append(styledMessages, chatMsg)
+			chatMsg = chat.Style.Chat.Msg.Sys.Width(msgWidth).Render(fullMsg)
 		case schema.UserMsg:
 			date := time.Unix(msg.Timestamp, 0).Format("2 Jan | 15:04")
 			username := user.Username
 			fullMsg := fmt.Sprintf("%s\n%s (%s) ", msg.Content, username, date)
-			chatMsg := chat.Style.Chat.Msg.User.Width(width).Render(fullMsg)
-
-			styledMessages = append(styledMessages, chatMsg)
-
+			chatMsg = chat.Style.Chat.Msg.User.Width(msgWidth).Render(fullMsg)
 		case schema.AIMsg:
+			if i == chat.Raw {
+				chatMsg = chat.Style.Chat.Msg.AI.Width(msgWidth).Render(msg.Content)
+				break
+			}
+
 			renderer, _ := glamour.NewTermRenderer(
 				glamour.WithStyles(chat.Style.Chat.Msg.Glamour),
-				glamour.WithWordWrap(width-6),
+				glamour.WithWordWrap(msgWidth-6),
 			)
 
-			renderedTxt, _ := renderer.Render(msg.Content)
+			renderedTxt, _ := renderer.Render(NumberCodeBlocks(msg.Content))
 
 			renderedTxt = replaceResets(renderedTxt, chat.Style.WhitespaceBGcolor)
-			chatMsg := chat.Style.Chat.Msg.AI.Width(width).Render(renderedTxt)
-			styledMessages = append(styledMessages, chatMsg)
+			chatMsg = chat.Style.Chat.Msg.AI.Width(msgWidth).Render(renderedTxt)
 		}
+
+		if i == chat.Selected {
+			chatMsg = chat.Style.Chat.Msg.Selected.Render(chatMsg)
+		}
+
+		offsets = append(offsets, offset)
+		offset += lipgloss.Height(chatMsg)
+
+		styledMessages = append(styledMessages, chatMsg)
 	}
 
-	return lipgloss.PlaceHorizontal(
+	if chat.offsets != nil {
+		*chat.offsets = offsets
+	}
+
+	return chat.highlight(lipgloss.PlaceHorizontal(
 		chat.WindowSize.Width,
 		lipgloss.Center,
 		lipgloss.JoinVertical(lipgloss.Center, styledMessages...),
 		lipgloss.WithWhitespaceBackground(lipgloss.Color(chat.Style.WhitespaceBGcolor)),
-	)
+	))
+}
+
+// Select selects the message at the index, -1 clears the selection, and
+// scrolls the transcript to it.
+func (chat ChatView) Select(index int) ChatView {
+	if index >= len(chat.Msgs) {
+		index = len(chat.Msgs) - 1
+	}
+
+	if index < -1 {
+		index = -1
+	}
+
+	chat.Selected = index
+	chat.Viewport.SetContent(chat.RenderMsgs())
+
+	if index < 0 || chat.offsets == nil || index >= len(*chat.offsets) {
+		return chat
+	}
+
+	// Show the start of the selected message, at the top when it's long
+	offset := (*chat.offsets)[index]
+	if offset < chat.Viewport.YOffset || offset >= chat.Viewport.YOffset+chat.Viewport.Height {
+		chat.Viewport.SetYOffset(offset)
+	}
+
+	return chat
 }
 
 func (chat ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -162,9 +259,13 @@ func (chat ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		chat.WindowSize = msg
 		chat.Viewport.Width = msg.Width
-		chat.Viewport.Height = msg.Height - chat.Input.LineInfo().Height - 7
+		chat.Viewport.Height = msg.Height - chat.Input.Height() - 7
 		chat.Viewport.SetContent(chat.RenderMsgs())
 		chat.Viewport.GotoBottom()
+	case RunDoneMsg:
+		chat.IsLoading = false
+	case EditorMsg:
+		return chat.HandleEditor(msg)
 	case spinner.TickMsg:
 		loader, cmd := chat.Loader.Update(msg)
 		chat.Loader = loader
@@ -203,43 +304,57 @@ func (chat ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return chat, chat.HandleStream
 
 	case tea.KeyMsg:
-		switch msg.Type {
-		case tea.KeyEnter:
-			prompt := chat.Input.Value()
-			menuActive := strings.HasPrefix(prompt, "/")
-			if !menuActive && chat.Input.Focused() {
-				input := chat.Input.Value()
+		if chat.Search.Active {
+			return chat.UpdateSearch(msg), nil
+		}
 
-				chat.Input.Reset()
-				chat.IsLoading = true
+		if chat.Find.Open {
+			find, handled := chat.UpdateFind(msg)
+			chat = find
 
-				userMsg := schema.Msg{
-					Stream:    false,
-					Content:   input,
-					Role:      schema.UserMsg,
-					Timestamp: time.Now().Unix(),
-				}
-
-				chat.Msgs = append(chat.Msgs, userMsg)
+			if handled {
+				return chat, nil
+			}
+		}
 
-				aiMsg := schema.Msg{
-					Stream:    true,
-					Content:   "",
-					Role:      schema.AIMsg,
-					Timestamp: time.Now().Unix(),
+		prompt := chat.Input.Value()
+		menuActive := strings.HasPrefix(prompt, "/")
+
+		switch {
+		case msg.Type == tea.KeyCtrlF:
+			return chat.OpenFind(""), nil
+		case msg.Type == tea.KeyCtrlR:
+			chat.Search = HistorySearch{Active: true, Match: len(chat.History.Entries), draft: prompt}
+			return chat, nil
+		case msg.Type == tea.KeyUp:
+			// Browse the history from the first line, move the cursor otherwise
+			if !menuActive && chat.Input.Line() == 0 {
+				if entry, ok := chat.History.Prev(prompt); ok {
+					chat.Input.SetValue(entry)
+					return chat, nil
 				}
+			}
+		case msg.Type == tea.KeyDown:
+			if !menuActive && chat.Input.Line() == strings.Count(prompt, "\n") {
+				if entry, ok := chat.History.Next(); ok {
+					chat.Input.SetValue(entry)
+					return chat, nil
+				}
+			}
+		case key.Matches(msg, chat.Keys.Newline):
+			// The menu uses ctrl+j to move down, it's only a newline in prompts
+			if !menuActive {
+				chat.Input.InsertString("\n")
+			}
 
-				chat.Msgs = append(chat.Msgs, aiMsg)
-
-				chat.Viewport.SetContent(chat.RenderMsgs())
-				chat.Viewport.GotoBottom()
-
-				go func() {
-					err := chat.Provider.Run(context.TODO(), input, chat.Session)
-					if err != nil {
-						log.Printf("Error: %v", err)
-					}
-				}()
+			return chat, nil
+		case key.Matches(msg, chat.Keys.Editor):
+			if !menuActive {
+				return chat, chat.OpenEditor()
+			}
+		case key.Matches(msg, chat.Keys.Send):
+			if !menuActive && chat.Input.Focused() {
+				return chat.Send(chat.Input.Value())
 			}
 
 			return chat, chat.Loader.Tick
@@ -257,10 +372,161 @@ func (chat ChatView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return chat, tea.Batch(cmds...)
 }
 
+type KeyMap struct {
+	Send    key.Binding
+	Newline key.Binding
+	Editor  key.Binding
+}
+
+func DefaultKeyMap() KeyMap {
+	return NewKeyMap([]string{"enter"}, []string{"alt+enter", "ctrl+j"})
+}
+
+// NewKeyMap binds sending and inserting a newline to the given keys, the
+// first key of each is shown in the help.
+func NewKeyMap(send []string, newline []string) KeyMap {
+	return KeyMap{
+		Send:    key.NewBinding(key.WithKeys(send...), key.WithHelp(send[0], "send")),
+		Newline: key.NewBinding(key.WithKeys(newline...), key.WithHelp(newline[0], "newline")),
+		Editor:  key.NewBinding(key.WithKeys("ctrl+e"), key.WithHelp("ctrl+e", "editor")),
+	}
+}
+
+// InputHeight is the number of rows the input needs for its content, one
+// more than the text takes and at most MaxInputHeight.
+func (chat ChatView) InputHeight() int {
+	width := max(chat.Input.Width(), 1)
+
+	rows := 0
+	for _, line := range strings.Split(chat.Input.Value(), "\n") {
+		rows += lipgloss.Width(line)/width + 1
+	}
+
+	height := rows + 1
+	if chat.MaxInputHeight > 0 && height > chat.MaxInputHeight {
+		height = chat.MaxInputHeight
+	}
+
+	return height
+}
+
+// Send adds the prompt to the transcript and runs the provider with it.
+func (chat ChatView) Send(input string) (ChatView, tea.Cmd) {
+	chat.History.Add(input)
+	chat.Input.Reset()
+	chat.IsLoading = true
+
+	userMsg := schema.Msg{
+		Stream:    false,
+		Content:   input,
+		Role:      schema.UserMsg,
+		Timestamp: time.Now().Unix(),
+	}
+
+	chat.Msgs = append(chat.Msgs, userMsg)
+
+	aiMsg := schema.Msg{
+		Stream:    true,
+		Content:   "",
+		Role:      schema.AIMsg,
+		Timestamp: time.Now().Unix(),
+	}
+
+	chat.Msgs = append(chat.Msgs, aiMsg)
+
+	chat.Viewport.SetContent(chat.RenderMsgs())
+	chat.Viewport.GotoBottom()
+
+	return chat, tea.Batch(chat.Loader.Tick, chat.RunProvider(input))
+}
+
+// RunDoneMsg is sent once the provider has finished handling a prompt.
+type RunDoneMsg struct {
+	SessionID string
+	Input     string
+	Err       error
+}
+
+// RunProvider runs the provider with the given input in the background and
+// reports back with a RunDoneMsg once it is done.
+func (chat ChatView) RunProvider(input string) tea.Cmd {
+	provider := chat.Provider
+	session := chat.Session
+
+	return func() tea.Msg {
+		err := provider.Run(context.TODO(), input, session)
+		if err != nil {
+			log.Printf("Error: %v", err)
+		}
+
+		return RunDoneMsg{SessionID: session.ID, Input: input, Err: err}
+	}
+}
+
+// UpdateSearch handles key presses during a reverse history search, the
+// input shows the current match.
+func (chat ChatView) UpdateSearch(msg tea.KeyMsg) ChatView {
+	search := chat.Search
+
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlG:
+		chat.Input.SetValue(search.draft)
+		chat.Search = HistorySearch{}
+		return chat
+	case tea.KeyCtrlR:
+		if match, ok := chat.History.Search(search.Query, search.Match); ok {
+			search.Match = match
+		}
+	case tea.KeyBackspace:
+		runes := []rune(search.Query)
+		if len(runes) > 0 {
+			search.Query = string(runes[:len(runes)-1])
+		}
+
+		search.Match = len(chat.History.Entries)
+		if match, ok := chat.History.Search(search.Query, search.Match); ok {
+			search.Match = match
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		search.Query += string(msg.Runes)
+
+		// Look for the longer query from the current match on
+		if match, ok := chat.History.Search(search.Query, search.Match+1); ok {
+			search.Match = match
+		}
+	default:
+		// Any other key accepts the match for editing
+		chat.Search = HistorySearch{}
+		return chat
+	}
+
+	chat.Search = search
+	if search.Match < len(chat.History.Entries) {
+		chat.Input.SetValue(chat.History.Entries[search.Match])
+	}
+
+	return chat
+}
+
+// Matched reports whether the search query matches the shown entry.
+func (search HistorySearch) Matched(history *History) bool {
+	return search.Match < len(history.Entries) &&
+		strings.Contains(strings.ToLower(history.Entries[search.Match]), strings.ToLower(search.Query))
+}
+
 func (chat ChatView) HandleStream() tea.Msg {
 	return <-chat.Stream
 }
 
+// DebugMsg carries a debug event reported by the provider.
+type DebugMsg struct {
+	Event schema.DebugEvent
+}
+
+func (chat ChatView) HandleDebug() tea.Msg {
+	return DebugMsg{Event: <-chat.Events}
+}
+
 func replaceResets(s string, bgColor string) string {
 	// Convert hex to RGB for truecolor background
 	r, g, b := hexToRGB(bgColor)
@@ -0,0 +1,155 @@
+package chat
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/struki84/clipt/tui/style"
+)
+
+type testProvider struct{}
+
+func (testProvider) Name() string              { return "test" }
+func (testProvider) Type() schema.ProviderType { return schema.LLM }
+func (testProvider) Description() string       { return "" }
+func (testProvider) Run(ctx context.Context, input string, session schema.ChatSession) error {
+	return nil
+}
+func (testProvider) Stream(ctx context.Context, callback func(ctx context.Context, msg schema.Msg) error) {
+}
+
+func newTestChat() ChatView {
+	chat := New(testProvider{}, style.Default(style.Dark))
+	chat.Msgs = []schema.Msg{}
+
+	// Sized like View does
+	chat.Input.Prompt = ""
+	chat.Input.ShowLineNumbers = false
+	chat.Input.SetWidth(20)
+
+	return chat
+}
+
+func press(chat ChatView, msg tea.KeyMsg) ChatView {
+	model, _ := chat.Update(msg)
+	return model.(ChatView)
+}
+
+func typeText(chat ChatView, text string) ChatView {
+	return press(chat, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+}
+
+func TestNewKeyMap(t *testing.T) {
+	keys := map[string]tea.KeyMsg{
+		"enter":     {Type: tea.KeyEnter},
+		"alt+enter": {Type: tea.KeyEnter, Alt: true},
+		"ctrl+j":    {Type: tea.KeyCtrlJ},
+		"ctrl+s":    {Type: tea.KeyCtrlS},
+	}
+
+	tests := []struct {
+		keys    KeyMap
+		send    []string
+		newline []string
+	}{
+		{DefaultKeyMap(), []string{"enter"}, []string{"alt+enter", "ctrl+j"}},
+		{NewKeyMap([]string{"ctrl+s"}, []string{"enter", "alt+enter"}), []string{"ctrl+s"}, []string{"enter", "alt+enter"}},
+	}
+
+	for _, test := range tests {
+		if test.keys.Send.Help().Key != test.send[0] || test.keys.Newline.Help().Key != test.newline[0] {
+			t.Errorf("Expected the first keys in the help, got %q and %q", test.keys.Send.Help().Key, test.keys.Newline.Help().Key)
+		}
+
+		for name, msg := range keys {
+			if msg.String() != name {
+				t.Fatalf("Expected the terminal to report %q, got %q", name, msg.String())
+			}
+
+			send := key.Matches(msg, test.keys.Send)
+			newline := key.Matches(msg, test.keys.Newline)
+
+			if send != (name == test.send[0]) || newline != slices.Contains(test.newline, name) {
+				t.Errorf("Unexpected bindings of %q: send %v, newline %v", name, send, newline)
+			}
+		}
+	}
+}
+
+func TestSendKey(t *testing.T) {
+	chat := newTestChat()
+	chat.Keys = NewKeyMap([]string{"ctrl+s"}, []string{"enter"})
+
+	chat = typeText(chat, "first")
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyEnter})
+	chat = typeText(chat, "second")
+
+	if chat.Input.Value() != "first\nsecond" || len(chat.Msgs) != 0 {
+		t.Fatalf("Expected enter to insert a newline, got %q", chat.Input.Value())
+	}
+
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyCtrlS})
+	if len(chat.Msgs) != 2 || chat.Msgs[0].Content != "first\nsecond" || chat.Input.Value() != "" {
+		t.Fatalf("Expected ctrl+s to send the prompt, got %v", chat.Msgs)
+	}
+}
+
+func TestDefaultKeys(t *testing.T) {
+	chat := newTestChat()
+
+	chat = typeText(chat, "first")
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyEnter, Alt: true})
+	chat = typeText(chat, "second")
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyCtrlJ})
+
+	if chat.Input.Value() != "first\nsecond\n" {
+		t.Fatalf("Expected alt+enter and ctrl+j to insert newlines, got %q", chat.Input.Value())
+	}
+
+	// In the menu the keys move the selection and run commands
+	chat.Input.SetValue("/sess")
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyCtrlJ})
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if chat.Input.Value() != "/sess" || len(chat.Msgs) != 0 {
+		t.Fatalf("Expected no newline or prompt sent in the menu, got %q", chat.Input.Value())
+	}
+
+	chat.Input.SetValue("hello")
+	chat = press(chat, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if len(chat.Msgs) != 2 || chat.Msgs[0].Content != "hello" || !chat.IsLoading {
+		t.Fatalf("Expected enter to send the prompt, got %v", chat.Msgs)
+	}
+}
+
+func TestInputHeight(t *testing.T) {
+	tests := []struct {
+		value string
+		max   int
+		rows  int
+	}{
+		{"", 10, 2},
+		{"hello", 10, 2},
+		{"one\ntwo\nthree", 10, 4},
+		{strings.Repeat("a", 45), 10, 4},
+		{strings.Repeat("line\n", 20), 10, 10},
+		{strings.Repeat("line\n", 20), 0, 22},
+		{"one\ntwo\nthree", 3, 3},
+	}
+
+	for _, test := range tests {
+		chat := newTestChat()
+		chat.MaxInputHeight = test.max
+		chat.Input.SetValue(test.value)
+
+		if height := chat.InputHeight(); height != test.rows {
+			t.Errorf("Expected %q to take %d rows with max %d, got %d", test.value, test.rows, test.max, height)
+		}
+	}
+}
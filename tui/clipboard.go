@@ -0,0 +1,72 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+)
+
+// Output is the terminal the program renders to. Writes are serialized so
+// the escape sequences clipt sends itself land between frames.
+type Output struct {
+	file *os.File
+	lock *sync.Mutex
+}
+
+func NewOutput(file *os.File) *Output {
+	return &Output{file: file, lock: &sync.Mutex{}}
+}
+
+func (output *Output) Read(p []byte) (int, error) { return output.file.Read(p) }
+func (output *Output) Close() error               { return output.file.Close() }
+func (output *Output) Fd() uintptr                { return output.file.Fd() }
+func (output *Output) Write(p []byte) (int, error) {
+	output.lock.Lock()
+	defer output.lock.Unlock()
+
+	return output.file.Write(p)
+}
+
+// Terminal reports whether the output is a terminal.
+func (output *Output) Terminal() bool {
+	info, err := output.file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// CopyText copies the text to the clipboard of the terminal with an OSC 52
+// sequence, which works over SSH and in tmux. Without a terminal it falls
+// back to the system clipboard.
+func (layout LayoutView) CopyText(text string) error {
+	if layout.Output != nil && layout.Output.Terminal() {
+		return layout.Output.copyOSC52(text)
+	}
+
+	err := clipboard.WriteAll(text)
+	if err != nil {
+		return fmt.Errorf("Error copying to clipboard: %v", err)
+	}
+
+	return nil
+}
+
+// copyOSC52 asks the terminal to set its clipboard, terminals that don't
+// support OSC 52 ignore the sequence.
+func (output *Output) copyOSC52(text string) error {
+	seq := osc52.New(text)
+	if os.Getenv("TMUX") != "" {
+		seq = seq.Tmux()
+	} else if strings.HasPrefix(os.Getenv("TERM"), "screen") {
+		seq = seq.Screen()
+	}
+
+	_, err := output.Write([]byte(seq.String()))
+	if err != nil {
+		return fmt.Errorf("Error copying to clipboard: %v", err)
+	}
+
+	return nil
+}
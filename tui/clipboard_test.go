@@ -0,0 +1,44 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyOSC52(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "tui_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	file, err := os.Create(filepath.Join(tempDir, "output"))
+	if err != nil {
+		t.Fatalf("Failed to create output: %v", err)
+	}
+	defer file.Close()
+
+	t.Setenv("TMUX", "")
+	t.Setenv("TERM", "xterm-256color")
+
+	output := NewOutput(file)
+	if output.Terminal() {
+		t.Error("Expected a file not to be a terminal")
+	}
+
+	err = output.copyOSC52("hi")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		t.Fatalf("Failed to read output: %v", err)
+	}
+
+	if !strings.Contains(string(data), "\x1b]52;c;aGk=\x07") {
+		t.Errorf("Expected an OSC 52 sequence, got %q", data)
+	}
+}
@@ -1,13 +1,18 @@
 package tui
 
 import (
-	"context"
 	"fmt"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/export"
+	"github.com/struki84/clipt/templates"
+	"github.com/struki84/clipt/tui/chat"
 	"github.com/struki84/clipt/tui/schema"
 	"github.com/thanhpk/randstr"
 )
@@ -21,14 +26,32 @@ type ProvidersCmd struct {
 func (cmd ProvidersCmd) Title() string       { return cmd.title }
 func (cmd ProvidersCmd) Description() string { return cmd.desc }
 func (cmd ProvidersCmd) FilterValue() string { return cmd.title }
+func (cmd ProvidersCmd) Usage() string       { return cmd.title + " [name]" }
 func (cmd ProvidersCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd ProvidersCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
 	layout := model.(LayoutView)
 	items := []list.Item{}
 
 	for _, provider := range layout.Providers {
-		if provider.Type() == cmd.filter {
-			items = append(items, ProviderCmd{provider: provider})
+		if provider.Type() != cmd.filter {
+			continue
+		}
+
+		// With a name switch to the provider right away
+		if args.From(0) == provider.Name() {
+			return ProviderCmd{provider: provider}.Execute(layout)
 		}
+
+		items = append(items, ProviderCmd{provider: provider})
+	}
+
+	if args.Len() > 0 {
+		layout.Menu = layout.Menu.Close()
+		layout.Chat.Input.SetValue("")
+
+		return layout.AddMsg(schema.ErrMsg, fmt.Sprintf("No provider named %q", args.From(0))), nil
 	}
 
 	layout.Menu = layout.Menu.PushMenu(items)
@@ -37,6 +60,17 @@ func (cmd ProvidersCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	return layout, nil
 }
 
+func (cmd ProvidersCmd) Complete(model tea.Model, args schema.Args) []string {
+	names := []string{}
+	for _, provider := range model.(LayoutView).Providers {
+		if provider.Type() == cmd.filter {
+			names = append(names, provider.Name())
+		}
+	}
+
+	return names
+}
+
 type ProviderCmd struct {
 	provider schema.ChatProvider
 }
@@ -47,12 +81,7 @@ func (cmd ProviderCmd) FilterValue() string { return cmd.provider.Name() }
 func (cmd ProviderCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	layout := model.(LayoutView)
 
-	layout.Chat.Provider = cmd.provider
-	layout.Chat.Provider.Stream(context.TODO(), func(ctx context.Context, msg schema.Msg) error {
-		layout.Chat.Stream <- msg
-		return nil
-	})
-
+	layout = layout.SwitchProvider(cmd.provider)
 	layout.Menu = layout.Menu.Close()
 	layout.Chat.Input.SetValue("")
 
@@ -69,37 +98,80 @@ func (cmd SessionsCmd) Description() string { return cmd.desc }
 func (cmd SessionsCmd) FilterValue() string { return cmd.title }
 func (cmd SessionsCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	layout := model.(LayoutView)
-	items := []list.Item{}
 
-	if layout.Storage != nil {
-		sessions := layout.Storage.ListSessions()
+	// Sessions are loaded in pages in the background and appended to the
+	// submenu as the selection reaches the end of the list
+	layout.Menu = layout.Menu.PushMenu([]list.Item{})
+	layout.Chat.Input.SetValue("/")
 
-		for _, session := range sessions {
-			items = append(items, SessionCmd{session: session})
-		}
+	if layout.Storage == nil {
+		return layout, nil
 	}
 
-	layout.Menu = layout.Menu.PushMenu(items)
-	layout.Chat.Input.SetValue("/")
+	layout.SessionPages = SessionPages{Active: true, Loading: true}
 
-	return layout, nil
+	return layout, layout.LoadSessionPage(0)
 }
 
 type SessionCmd struct {
-	session schema.ChatSession
+	session schema.SessionSummary
 }
 
 func (cmd SessionCmd) Title() string { return "/" + cmd.session.Title }
 func (cmd SessionCmd) Description() string {
-	return time.Unix(cmd.session.CreatedAt, 0).Format("2 Jan 2006")
+	parts := []string{time.Unix(lastUsed(cmd.session), 0).Format("2 Jan 2006")}
+	parts = append(parts, fmt.Sprintf("%d msgs", cmd.session.MsgCount))
+
+	if cmd.session.Pinned {
+		parts = append(parts, "pinned")
+	}
+
+	if len(cmd.session.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(cmd.session.Tags, " #"))
+	}
+
+	if cmd.session.Provider != "" {
+		parts = append(parts, cmd.session.Provider)
+	}
+
+	if cmd.session.ParentID != "" {
+		parent := cmd.session.ParentTitle
+		if parent == "" {
+			parent = cmd.session.ParentID
+		}
+
+		parts = append(parts, "fork of "+parent)
+	}
+
+	desc := strings.Join(parts, " | ")
+
+	// Fill the remaining room with the last message
+	room := 58 - len([]rune(desc)) - 3
+	if cmd.session.Snippet != "" && room > 8 {
+		desc += " | " + snippet(cmd.session.Snippet, room)
+	}
+
+	return desc
+}
+
+// FilterValue includes the tags so sessions can be filtered by "#tag".
+func (cmd SessionCmd) FilterValue() string {
+	if len(cmd.session.Tags) == 0 {
+		return cmd.session.Title
+	}
+
+	return cmd.session.Title + " #" + strings.Join(cmd.session.Tags, " #")
 }
-func (cmd SessionCmd) FilterValue() string { return cmd.session.Title }
 func (cmd SessionCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	layout := model.(LayoutView)
-	layout.Chat.Session = cmd.session
-	layout.Chat.Msgs = cmd.session.Msgs
-	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
-	layout.Chat.Viewport.GotoBottom()
+
+	session, err := layout.Storage.LoadSession(cmd.session.ID)
+	if err != nil {
+		layout = layout.AddMsg(schema.ErrMsg, err.Error())
+	} else {
+		layout = layout.OpenSession(session)
+	}
+
 	layout.Chat.Input.SetValue("")
 
 	layout.Menu = layout.Menu.Close()
@@ -123,12 +195,7 @@ func (cmd NewSessionCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	if layout.Storage != nil {
 		session, _ = layout.Storage.NewSession()
 	} else {
-		sessionID := randstr.String(8)
-		session = schema.ChatSession{
-			ID:        sessionID,
-			Title:     fmt.Sprintf("Session - %s", sessionID),
-			CreatedAt: time.Now().Unix(),
-		}
+		session = newSession()
 	}
 
 	layout.Chat.Session = session
@@ -152,34 +219,939 @@ func (cmd DeleteSessionCmd) Description() string { return cmd.desc }
 func (cmd DeleteSessionCmd) FilterValue() string { return cmd.title }
 func (cmd DeleteSessionCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
 	layout := model.(LayoutView)
+	session := layout.Chat.Session
+
+	items := []list.Item{
+		ConfirmCmd{
+			title: "/yes",
+			desc:  fmt.Sprintf("Move \"%s\" to the trash", session.Title),
+			action: func(layout LayoutView) LayoutView {
+				return layout.DeleteSession(session.ID)
+			},
+		},
+		CancelCmd{title: "/no", desc: "Keep the session"},
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type TrashCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd TrashCmd) Title() string       { return cmd.title }
+func (cmd TrashCmd) Description() string { return cmd.desc }
+func (cmd TrashCmd) FilterValue() string { return cmd.title }
+func (cmd TrashCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	items := []list.Item{}
+
+	storage, ok := layout.Storage.(schema.TrashStorage)
+	if !ok {
+		layout.Menu = layout.Menu.Close()
+		layout.Chat.Input.SetValue("")
+
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't keep a trash"), nil
+	}
+
+	sessions := storage.ListTrash()
+
+	if len(sessions) > 0 {
+		items = append(items, ConfirmCmd{
+			title: "/empty",
+			desc:  fmt.Sprintf("Permanently delete all %d sessions in the trash", len(sessions)),
+			action: func(layout LayoutView) LayoutView {
+				count, err := storage.PurgeTrash(0)
+				if err != nil {
+					return layout.AddMsg(schema.ErrMsg, err.Error())
+				}
+
+				return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Purged %d sessions from the trash", count))
+			},
+		})
+	}
+
+	for _, session := range sessions {
+		items = append(items, TrashedSessionCmd{storage: storage, session: session})
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type TrashedSessionCmd struct {
+	storage schema.TrashStorage
+	session schema.ChatSession
+}
+
+func (cmd TrashedSessionCmd) Title() string { return "/" + cmd.session.Title }
+func (cmd TrashedSessionCmd) Description() string {
+	return "deleted " + time.Unix(cmd.session.DeletedAt, 0).Format("2 Jan 2006")
+}
+func (cmd TrashedSessionCmd) FilterValue() string { return cmd.session.Title }
+func (cmd TrashedSessionCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	session := cmd.session
+
+	items := []list.Item{
+		ConfirmCmd{
+			title: "/restore",
+			desc:  fmt.Sprintf("Restore \"%s\" and open it", session.Title),
+			action: func(layout LayoutView) LayoutView {
+				err := cmd.storage.RestoreSession(session.ID)
+				if err != nil {
+					return layout.AddMsg(schema.ErrMsg, err.Error())
+				}
+
+				session.DeletedAt = 0
+				return layout.OpenSession(session)
+			},
+		},
+		ConfirmCmd{
+			title: "/purge",
+			desc:  fmt.Sprintf("Permanently delete \"%s\"", session.Title),
+			action: func(layout LayoutView) LayoutView {
+				err := cmd.storage.PurgeSession(session.ID)
+				if err != nil {
+					return layout.AddMsg(schema.ErrMsg, err.Error())
+				}
+
+				return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Purged \"%s\"", session.Title))
+			},
+		},
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+// ConfirmCmd runs its action when selected, used for the choices of
+// confirmation and action submenus.
+type ConfirmCmd struct {
+	title  string
+	desc   string
+	action func(LayoutView) LayoutView
+}
+
+func (cmd ConfirmCmd) Title() string       { return cmd.title }
+func (cmd ConfirmCmd) Description() string { return cmd.desc }
+func (cmd ConfirmCmd) FilterValue() string { return cmd.title }
+func (cmd ConfirmCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+	layout = cmd.action(layout)
+
+	return layout, nil
+}
+
+type CancelCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd CancelCmd) Title() string       { return cmd.title }
+func (cmd CancelCmd) Description() string { return cmd.desc }
+func (cmd CancelCmd) FilterValue() string { return cmd.title }
+func (cmd CancelCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return layout, nil
+}
+
+type ForkCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd ForkCmd) Title() string       { return cmd.title }
+func (cmd ForkCmd) Description() string { return cmd.desc }
+func (cmd ForkCmd) FilterValue() string { return cmd.title }
+func (cmd ForkCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	items := []list.Item{}
+
+	if _, ok := layout.Storage.(schema.ForkableStorage); layout.Storage != nil && !ok {
+		layout.Menu = layout.Menu.Close()
+		layout.Chat.Input.SetValue("")
+
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't support forking sessions"), nil
+	}
+
+	// List the most recent messages first, they are the usual fork points
+	for i := len(layout.Chat.Msgs) - 1; i >= 0; i-- {
+		msg := layout.Chat.Msgs[i]
+		if msg.Role != schema.UserMsg && msg.Role != schema.AIMsg {
+			continue
+		}
+
+		items = append(items, ForkMsgCmd{index: i, msg: msg})
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type ForkMsgCmd struct {
+	index int
+	msg   schema.Msg
+}
+
+func (cmd ForkMsgCmd) Title() string {
+	return fmt.Sprintf("/%d %s", cmd.index+1, snippet(cmd.msg.Content, 48))
+}
+func (cmd ForkMsgCmd) Description() string {
+	date := time.Unix(cmd.msg.Timestamp, 0).Format("2 Jan | 15:04")
+	if cmd.msg.Role == schema.UserMsg {
+		return "you, " + date
+	}
+
+	return "ai, " + date
+}
+func (cmd ForkMsgCmd) FilterValue() string { return cmd.msg.Content }
+func (cmd ForkMsgCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	parent := layout.Chat.Session
+
+	var session schema.ChatSession
+	if storage, ok := layout.Storage.(schema.ForkableStorage); ok {
+		// The transcript holds notes that aren't stored, fork at the stored
+		// message with the same ID
+		stored, err := layout.Storage.LoadSession(parent.ID)
+		if err == nil {
+			index := storedIndex(layout.Chat.Msgs, stored.Msgs, cmd.index)
+			if index < 0 {
+				err = fmt.Errorf("Error forking session: the message isn't saved")
+			} else {
+				session, err = storage.ForkSession(parent.ID, index)
+			}
+		}
+
+		if err != nil {
+			log.Printf("Error while forking session: %s", err)
+			layout.Menu = layout.Menu.Close()
+			layout.Chat.Input.SetValue("")
+			return layout.AddMsg(schema.ErrMsg, err.Error()), nil
+		}
+	} else {
+		sessionID := randstr.String(8)
+		session = schema.ChatSession{
+			ID:        sessionID,
+			ParentID:  parent.ID,
+			Title:     fmt.Sprintf("%s (fork)", parent.Title),
+			Msgs:      append([]schema.Msg{}, layout.Chat.Msgs[:cmd.index+1]...),
+			CreatedAt: time.Now().Unix(),
+		}
+	}
+
+	layout.Chat.Session = session
+	layout.Chat.Msgs = session.Msgs
+	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
+	layout.Chat.Viewport.GotoBottom()
+	layout.Chat.Input.SetValue("")
+
+	layout.Menu = layout.Menu.Close()
+
+	return layout, nil
+}
+
+// storedIndex maps the index of a message in the transcript to the index of
+// the stored message with its ID, -1 when it isn't stored.
+func storedIndex(msgs []schema.Msg, stored []schema.Msg, index int) int {
+	if index < 0 || index >= len(msgs) || msgs[index].ID == "" {
+		return -1
+	}
+
+	for i, msg := range stored {
+		if msg.ID == msgs[index].ID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// FindCmd searches the transcript for the text typed after the command,
+// without it the query is typed like after ctrl+f.
+type FindCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd FindCmd) Title() string       { return cmd.title }
+func (cmd FindCmd) Description() string { return cmd.desc }
+func (cmd FindCmd) FilterValue() string { return cmd.title }
+func (cmd FindCmd) Usage() string       { return cmd.title + " [text]" }
+func (cmd FindCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd FindCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	query := args.Raw
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+	layout.Chat = layout.Chat.OpenFind(query)
+
+	return layout, nil
+}
+
+type InfoCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd InfoCmd) Title() string       { return cmd.title }
+func (cmd InfoCmd) Description() string { return cmd.desc }
+func (cmd InfoCmd) FilterValue() string { return cmd.title }
+func (cmd InfoCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	items := []list.Item{}
+
+	for i := len(layout.Chat.Msgs) - 1; i >= 0; i-- {
+		msg := layout.Chat.Msgs[i]
+		if msg.Role != schema.UserMsg && msg.Role != schema.AIMsg {
+			continue
+		}
+
+		items = append(items, MsgInfoCmd{index: i, msg: msg})
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type MsgInfoCmd struct {
+	index int
+	msg   schema.Msg
+}
+
+func (cmd MsgInfoCmd) Title() string {
+	return fmt.Sprintf("/%d %s", cmd.index+1, snippet(cmd.msg.Content, 48))
+}
+func (cmd MsgInfoCmd) Description() string {
+	return ForkMsgCmd{msg: cmd.msg}.Description()
+}
+func (cmd MsgInfoCmd) FilterValue() string { return cmd.msg.Content }
+func (cmd MsgInfoCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return layout.AddMsg(schema.InternalMsg, MsgInfo(cmd.msg)), nil
+}
+
+// MsgInfo formats the ID and metadata of a message for the transcript.
+func MsgInfo(msg schema.Msg) string {
+	date := time.Unix(msg.Timestamp, 0).Format("2 Jan 2006 15:04:05")
+	lines := []string{fmt.Sprintf("Message %s, %s", msg.ID, date)}
+
+	if msg.ID == "" {
+		lines[0] = fmt.Sprintf("Unsaved message, %s", date)
+	}
+
+	keys := []string{}
+	for key := range msg.Meta {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		lines = append(lines, fmt.Sprintf("- %s: %s", key, msg.Meta[key]))
+	}
+
+	if len(keys) == 0 {
+		lines = append(lines, "No metadata recorded")
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// CopyCmd copies the last answer, or with a number its code block, to the
+// clipboard. Without arguments it lists what can be copied.
+type CopyCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd CopyCmd) Title() string       { return cmd.title }
+func (cmd CopyCmd) Description() string { return cmd.desc }
+func (cmd CopyCmd) FilterValue() string { return cmd.title }
+func (cmd CopyCmd) Usage() string       { return cmd.title + " [n]" }
+func (cmd CopyCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd CopyCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	answer := layout.lastAnswer()
+
+	if args.Len() == 0 {
+		items := []list.Item{}
+
+		if answer >= 0 {
+			for i, block := range chat.CodeBlocks(layout.Chat.Msgs[answer].Content) {
+				items = append(items, CopyMsgCmd{index: answer, msg: layout.Chat.Msgs[answer], block: i + 1, code: block})
+			}
+		}
+
+		for i := len(layout.Chat.Msgs) - 1; i >= 0; i-- {
+			msg := layout.Chat.Msgs[i]
+			if msg.Role == schema.UserMsg || msg.Role == schema.AIMsg {
+				items = append(items, CopyMsgCmd{index: i, msg: msg})
+			}
+		}
+
+		layout.Menu = layout.Menu.PushMenu(items)
+		layout.Chat.Input.SetValue("/")
+
+		return layout, nil
+	}
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	if answer < 0 {
+		return layout.AddMsg(schema.ErrMsg, "There's no answer to copy"), nil
+	}
+
+	number, err := strconv.Atoi(args.Get(0))
+	if err != nil || number < 1 {
+		return layout.AddMsg(schema.ErrMsg, "Usage: "+cmd.Usage()), nil
+	}
+
+	return layout.CopyCodeBlock(layout.Chat.Msgs[answer], number), nil
+}
+
+// Complete suggests the numbers of the code blocks in the last answer.
+func (cmd CopyCmd) Complete(model tea.Model, args schema.Args) []string {
+	layout := model.(LayoutView)
+	numbers := []string{}
+
+	answer := layout.lastAnswer()
+	if answer < 0 || args.Current() > 0 {
+		return numbers
+	}
+
+	for i := range chat.CodeBlocks(layout.Chat.Msgs[answer].Content) {
+		numbers = append(numbers, strconv.Itoa(i+1))
+	}
+
+	return numbers
+}
+
+// lastAnswer is the index of the last answer, -1 without one.
+func (layout LayoutView) lastAnswer() int {
+	for i := len(layout.Chat.Msgs) - 1; i >= 0; i-- {
+		if layout.Chat.Msgs[i].Role == schema.AIMsg {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// CopyMsgCmd copies a message, or one of its code blocks when block is set.
+type CopyMsgCmd struct {
+	index int
+	msg   schema.Msg
+	block int
+	code  string
+}
+
+func (cmd CopyMsgCmd) Title() string {
+	if cmd.block > 0 {
+		return fmt.Sprintf("/code %d %s", cmd.block, snippet(cmd.code, 40))
+	}
+
+	return fmt.Sprintf("/%d %s", cmd.index+1, snippet(cmd.msg.Content, 48))
+}
+func (cmd CopyMsgCmd) Description() string {
+	if cmd.block > 0 {
+		return fmt.Sprintf("Copy code block %d of the last answer", cmd.block)
+	}
+
+	return ForkMsgCmd{msg: cmd.msg}.Description()
+}
+func (cmd CopyMsgCmd) FilterValue() string { return cmd.Title() }
+func (cmd CopyMsgCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	if cmd.block > 0 {
+		return layout.CopyMsg(cmd.code, fmt.Sprintf("Code block %d copied", cmd.block)), nil
+	}
+
+	return layout.CopyMsg(cmd.msg.Content, "Message copied"), nil
+}
+
+type RateCmd struct {
+	title  string
+	desc   string
+	rating string
+}
+
+func (cmd RateCmd) Title() string       { return cmd.title }
+func (cmd RateCmd) Description() string { return cmd.desc }
+func (cmd RateCmd) FilterValue() string { return cmd.title }
+func (cmd RateCmd) Usage() string       { return cmd.title + " [note]" }
+func (cmd RateCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd RateCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	// The note is kept while picking the answer
+	note := args.Raw
+	items := []list.Item{}
+
+	for i := len(layout.Chat.Msgs) - 1; i >= 0; i-- {
+		msg := layout.Chat.Msgs[i]
+		if msg.Role != schema.AIMsg {
+			continue
+		}
+
+		items = append(items, RateMsgCmd{index: i, msg: msg, rating: cmd.rating, note: note})
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type RateMsgCmd struct {
+	index  int
+	msg    schema.Msg
+	rating string
+	note   string
+}
+
+func (cmd RateMsgCmd) Title() string {
+	return fmt.Sprintf("/%d %s", cmd.index+1, snippet(cmd.msg.Content, 48))
+}
+func (cmd RateMsgCmd) Description() string {
+	desc := ForkMsgCmd{msg: cmd.msg}.Description()
+	if rating := cmd.msg.Meta[schema.MetaRating]; rating != "" {
+		desc += ", rated " + rating
+	}
+
+	return desc
+}
+func (cmd RateMsgCmd) FilterValue() string { return cmd.msg.Content }
+func (cmd RateMsgCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout = layout.RateMsg(cmd.index, cmd.rating, cmd.note)
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return layout, nil
+}
+
+type DatasetCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd DatasetCmd) Title() string       { return cmd.title }
+func (cmd DatasetCmd) Description() string { return cmd.desc }
+func (cmd DatasetCmd) FilterValue() string { return cmd.title }
+func (cmd DatasetCmd) Usage() string       { return cmd.title + " [path]" }
+func (cmd DatasetCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd DatasetCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	path := args.From(0)
+	if path == "" {
+		path = "clipt-ratings.jsonl"
+	}
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	if layout.Storage == nil {
+		return layout.AddMsg(schema.ErrMsg, "Ratings are only kept with a storage"), nil
+	}
+
+	return layout, layout.ExportDataset(path)
+}
+
+type TemplatesCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd TemplatesCmd) Title() string       { return cmd.title }
+func (cmd TemplatesCmd) Description() string { return cmd.desc }
+func (cmd TemplatesCmd) FilterValue() string { return cmd.title }
+func (cmd TemplatesCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	if layout.TemplateDir == "" {
+		layout.Menu = layout.Menu.Close()
+		layout.Chat.Input.SetValue("")
+		return layout.AddMsg(schema.ErrMsg, "No template directory configured, see clipt.WithTemplates"), nil
+	}
+
+	// Templates are read on every open so edited files show up right away
+	library, err := templates.Load(layout.TemplateDir)
+	if err != nil {
+		log.Printf("Error while loading templates: %s", err)
+		layout.Menu = layout.Menu.Close()
+		layout.Chat.Input.SetValue("")
+		return layout.AddMsg(schema.ErrMsg, err.Error()), nil
+	}
+
+	items := []list.Item{}
+	for _, template := range library {
+		items = append(items, TemplateCmd{template: template})
+	}
+
+	layout.Menu = layout.Menu.PushMenu(items)
+	layout.Chat.Input.SetValue("/")
+
+	return layout, nil
+}
+
+type TemplateCmd struct {
+	template templates.Template
+}
+
+func (cmd TemplateCmd) Title() string { return "/" + cmd.template.Name }
+func (cmd TemplateCmd) Description() string {
+	return snippet(cmd.template.Body, 64)
+}
+func (cmd TemplateCmd) FilterValue() string { return cmd.template.Name }
+func (cmd TemplateCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	if len(cmd.template.Vars) == 0 {
+		layout.Chat.Input.SetValue(cmd.template.Body)
+		return layout, nil
+	}
+
+	layout.Form = NewTemplateForm(cmd.template)
+
+	return layout, nil
+}
+
+type RenameCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd RenameCmd) Title() string       { return cmd.title }
+func (cmd RenameCmd) Description() string { return cmd.desc }
+func (cmd RenameCmd) FilterValue() string { return cmd.title }
+func (cmd RenameCmd) Usage() string       { return cmd.title + " <title>" }
+func (cmd RenameCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd RenameCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	title := args.Text()
+	if title == "" {
+		return layout.AddMsg(schema.ErrMsg, "usage: "+cmd.Usage()), nil
+	}
+
+	return layout.RenameSession(layout.Chat.Session.ID, title), nil
+}
+
+// Complete suggests the current title for editing.
+func (cmd RenameCmd) Complete(model tea.Model, args schema.Args) []string {
+	title := model.(LayoutView).Chat.Session.Title
+	if title == "" || args.Current() > 0 {
+		return []string{}
+	}
+
+	return []string{title}
+}
+
+type ExportCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd ExportCmd) Title() string       { return cmd.title }
+func (cmd ExportCmd) Description() string { return cmd.desc }
+func (cmd ExportCmd) FilterValue() string { return cmd.title }
+func (cmd ExportCmd) Usage() string       { return cmd.title + " [md|json|html] [path|clipboard]" }
+func (cmd ExportCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd ExportCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	if args.Len() == 0 {
+		items := []list.Item{
+			ExportFormatCmd{format: export.Markdown},
+			ExportFormatCmd{format: export.JSON},
+			ExportFormatCmd{format: export.HTML},
+			ExportFormatCmd{format: export.Markdown, clipboard: true},
+		}
+
+		layout.Menu = layout.Menu.PushMenu(items)
+		layout.Chat.Input.SetValue("/")
+
+		return layout, nil
+	}
+
+	format, err := export.ParseFormat(args.Get(0))
+	if err != nil {
+		layout = layout.AddMsg(schema.ErrMsg, err.Error())
+	} else {
+		layout = layout.ExportSession(format, args.From(1))
+	}
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return layout, nil
+}
+
+func (cmd ExportCmd) Complete(model tea.Model, args schema.Args) []string {
+	switch args.Current() {
+	case 0:
+		return []string{"md", "json", "html"}
+	case 1:
+		return []string{"clipboard"}
+	}
+
+	return []string{}
+}
+
+type ExportFormatCmd struct {
+	format    export.Format
+	clipboard bool
+}
+
+func (cmd ExportFormatCmd) Title() string {
+	if cmd.clipboard {
+		return "/clipboard"
+	}
+
+	return "/" + cmd.format.String()
+}
+func (cmd ExportFormatCmd) Description() string {
+	if cmd.clipboard {
+		return "Copy the session as markdown to the clipboard"
+	}
+
+	return fmt.Sprintf("Export the session to a %s file", cmd.format.Ext())
+}
+func (cmd ExportFormatCmd) FilterValue() string { return cmd.Title() }
+func (cmd ExportFormatCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
 
-	sessionID := randstr.String(8)
-	newSession := schema.ChatSession{
-		ID:        sessionID,
-		Title:     fmt.Sprintf("Session - %s", sessionID),
-		CreatedAt: time.Now().Unix(),
+	target := ""
+	if cmd.clipboard {
+		target = "clipboard"
 	}
 
+	layout = layout.ExportSession(cmd.format, target)
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return layout, nil
+}
+
+type PinCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd PinCmd) Title() string       { return cmd.title }
+func (cmd PinCmd) Description() string { return cmd.desc }
+func (cmd PinCmd) FilterValue() string { return cmd.title }
+func (cmd PinCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	pinned := !layout.Chat.Session.Pinned
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
 	if layout.Storage != nil {
-		err := layout.Storage.DeleteSession(layout.Chat.Session.ID)
+		err := metadataStorage(layout.Storage).PinSession(layout.Chat.Session.ID, pinned)
 		if err != nil {
-			log.Printf("Error while deleting sessions: %s", err)
+			log.Printf("Error while pinning session: %s", err)
+			return layout.AddMsg(schema.ErrMsg, err.Error()), nil
 		}
+	}
+
+	layout.Chat.Session.Pinned = pinned
 
-		newSession, _ = layout.Storage.LoadRecentSession()
+	if pinned {
+		return layout.AddMsg(schema.InternalMsg, "Session pinned"), nil
 	}
 
-	layout.Chat.Msgs = []schema.Msg{}
-	layout.Chat.Session = newSession
+	return layout.AddMsg(schema.InternalMsg, "Session unpinned"), nil
+}
 
-	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
-	layout.Menu.Close()
+// TagCmd adds the tags typed after the command to the current session, or
+// removes them when remove is set.
+type TagCmd struct {
+	title  string
+	desc   string
+	remove bool
+}
 
+func (cmd TagCmd) Title() string       { return cmd.title }
+func (cmd TagCmd) Description() string { return cmd.desc }
+func (cmd TagCmd) FilterValue() string { return cmd.title }
+func (cmd TagCmd) Usage() string       { return cmd.title + " [tag]..." }
+func (cmd TagCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd TagCmd) ExecuteArgs(model tea.Model, tagArgs schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+	args := tagArgs.Words
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	tags := []string{}
+	for _, tag := range layout.Chat.Session.Tags {
+		removed := slices.Contains(args, "#"+tag) || slices.Contains(args, tag)
+		if !cmd.remove || !removed {
+			tags = append(tags, tag)
+		}
+	}
+
+	if !cmd.remove {
+		for _, arg := range args {
+			tag := strings.TrimPrefix(arg, "#")
+			if tag != "" && !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+
+	if len(args) > 0 {
+		if layout.Storage != nil {
+			err := metadataStorage(layout.Storage).SetSessionTags(layout.Chat.Session.ID, tags)
+			if err != nil {
+				log.Printf("Error while tagging session: %s", err)
+				return layout.AddMsg(schema.ErrMsg, err.Error()), nil
+			}
+		}
+
+		layout.Chat.Session.Tags = tags
+	}
+
+	if len(tags) == 0 {
+		return layout.AddMsg(schema.InternalMsg, "Session has no tags"), nil
+	}
+
+	return layout.AddMsg(schema.InternalMsg, "Session tags: #"+strings.Join(tags, " #")), nil
+}
+
+// Complete suggests the tags of the session for removing them.
+func (cmd TagCmd) Complete(model tea.Model, args schema.Args) []string {
+	tags := []string{}
+	if !cmd.remove {
+		return tags
+	}
+
+	for _, tag := range model.(LayoutView).Chat.Session.Tags {
+		if !slices.Contains(args.Words[:args.Current()], tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+type RekeyCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd RekeyCmd) Title() string       { return cmd.title }
+func (cmd RekeyCmd) Description() string { return cmd.desc }
+func (cmd RekeyCmd) FilterValue() string { return cmd.title }
+func (cmd RekeyCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
 	layout.Chat.Input.SetValue("")
 
+	if _, ok := layout.Storage.(schema.LockableStorage); !ok {
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't support encryption"), nil
+	}
+
+	layout.Prompt = NewPassphrasePrompt(RekeyPrompt, "New passphrase")
+
 	return layout, nil
 }
 
+// RetentionCmd shows which sessions the retention policy would remove, and
+// applies it when run as "/retention apply".
+type RetentionCmd struct {
+	title string
+	desc  string
+}
+
+func (cmd RetentionCmd) Title() string       { return cmd.title }
+func (cmd RetentionCmd) Description() string { return cmd.desc }
+func (cmd RetentionCmd) FilterValue() string { return cmd.title }
+func (cmd RetentionCmd) Usage() string       { return cmd.title + " [apply]" }
+func (cmd RetentionCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return cmd.ExecuteArgs(model, cmdArgs(model))
+}
+func (cmd RetentionCmd) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	storage, ok := layout.Storage.(schema.RetentionStorage)
+	if !ok {
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't support retention policies"), nil
+	}
+
+	if !layout.Retention.Enabled() {
+		return layout.AddMsg(schema.InternalMsg, "No retention policy configured"), nil
+	}
+
+	return layout, layout.RequestRetention(storage, args.Get(0) != "apply")
+}
+
+func (cmd RetentionCmd) Complete(model tea.Model, args schema.Args) []string {
+	if args.Current() > 0 {
+		return []string{}
+	}
+
+	return []string{"apply"}
+}
+
 type ExitCmd struct {
 	title string
 	desc  string
@@ -197,6 +1169,43 @@ var DefaultCmds = []list.Item{
 	ProvidersCmd{title: "/agents", desc: "List available agents", filter: schema.Agent},
 	SessionsCmd{title: "/sessions", desc: "List saved sessions"},
 	NewSessionCmd{title: "/new", desc: "Start new session"},
-	DeleteSessionCmd{title: "/delete", desc: "Delete the current session"},
+	DeleteSessionCmd{title: "/delete", desc: "Move the current session to the trash"},
+	TrashCmd{title: "/trash", desc: "Restore or purge deleted sessions"},
+	ForkCmd{title: "/fork", desc: "Fork the current session from a message"},
+	FindCmd{title: "/find", desc: "Search the transcript, /find [text] or ctrl+f"},
+	InfoCmd{title: "/info", desc: "Show the metadata of a message"},
+	CopyCmd{title: "/copy", desc: "Copy a message or code block, /copy [n]"},
+	RateCmd{title: "/good", desc: "Rate an answer as good, /good [note]", rating: schema.RatingGood},
+	RateCmd{title: "/bad", desc: "Rate an answer as bad, /bad [note]", rating: schema.RatingBad},
+	DatasetCmd{title: "/dataset", desc: "Export rated answers as JSONL, /dataset [path]"},
+	RenameCmd{title: "/rename", desc: "Rename the current session, /rename <title>"},
+	PinCmd{title: "/pin", desc: "Pin or unpin the current session"},
+	TagCmd{title: "/tag", desc: "Tag the current session or list its tags, /tag [tag]..."},
+	TagCmd{title: "/untag", desc: "Remove tags from the session, /untag [tag]...", remove: true},
+	RetentionCmd{title: "/retention", desc: "Preview the retention policy, /retention apply to enforce it"},
+	RekeyCmd{title: "/rekey", desc: "Encrypt the sessions with a new passphrase"},
+	TemplatesCmd{title: "/templates", desc: "Insert a prompt template"},
+	ExportCmd{title: "/export", desc: "Export the session, /export [md|json|html] [path|clipboard]"},
 	ExitCmd{title: "/exit", desc: "Close tui chat app"},
 }
+
+// lastUsed returns when the session was last updated, or created when the
+// storage doesn't track updates.
+func lastUsed(session schema.SessionSummary) int64 {
+	if session.UpdatedAt > 0 {
+		return session.UpdatedAt
+	}
+
+	return session.CreatedAt
+}
+
+// snippet returns the first line of the text, shortened to at most max runes.
+func snippet(text string, max int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	runes := []rune(line)
+	if len(runes) > max {
+		return string(runes[:max-1]) + "…"
+	}
+
+	return line
+}
@@ -0,0 +1,112 @@
+package tui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/struki84/clipt/storage"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// pinFailStorage fails to pin sessions.
+type pinFailStorage struct {
+	*storage.SQLite
+}
+
+func (pinFailStorage) PinSession(id string, pinned bool) error {
+	return errors.New("Error pinning session: disk full")
+}
+
+func TestForkMsgCmd(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{1: "Copied to the clipboard"})
+	parentID := layout.Chat.Session.ID
+
+	// The note shifts the second question to index 3 of the transcript
+	if layout.Chat.Msgs[3].Content != "second question" {
+		t.Fatalf("Unexpected transcript: %v", layout.Chat.Msgs)
+	}
+
+	model, _ := ForkMsgCmd{index: 3, msg: layout.Chat.Msgs[3]}.Execute(layout)
+	layout = model.(LayoutView)
+
+	if layout.Chat.Session.ParentID != parentID {
+		t.Fatalf("Expected a fork of %s, got %+v", parentID, layout.Chat.Session)
+	}
+
+	msgs := layout.Chat.Msgs
+	if len(msgs) != 3 || msgs[2].Content != "second question" {
+		t.Errorf("Expected the fork to end with the second question, got %v", msgs)
+	}
+
+	if storedIndex(layout.Chat.Msgs, nil, 0) != -1 {
+		t.Error("Expected no stored index for a message that isn't stored")
+	}
+}
+
+func TestForkMsgCmdNote(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+	layout = layout.AddMsg(schema.InternalMsg, "A note")
+	sessionID := layout.Chat.Session.ID
+
+	model, _ := ForkMsgCmd{index: 4, msg: layout.Chat.Msgs[4]}.Execute(layout)
+	layout = model.(LayoutView)
+
+	if layout.Chat.Session.ID != sessionID {
+		t.Errorf("Expected notes not to be forked from, got session %+v", layout.Chat.Session)
+	}
+
+	last := layout.Chat.Msgs[len(layout.Chat.Msgs)-1]
+	if last.Role != schema.ErrMsg {
+		t.Errorf("Expected an error message, got %v", last)
+	}
+}
+
+func TestRetentionCmd(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+	layout.Retention = schema.RetentionPolicy{MaxSessions: 10}
+	count := len(layout.Chat.Msgs)
+
+	model, cmd := RetentionCmd{title: "/retention"}.ExecuteArgs(layout, schema.ParseArgs("/retention"))
+	layout = model.(LayoutView)
+
+	if cmd == nil || len(layout.Chat.Msgs) != count {
+		t.Fatal("Expected the policy to be previewed in the background")
+	}
+
+	msg, ok := cmd().(RetentionMsg)
+	if !ok || !msg.Requested || !msg.Report.DryRun {
+		t.Fatalf("Expected a requested dry run, got %+v", msg)
+	}
+
+	model, _ = layout.Update(msg)
+	layout = model.(LayoutView)
+
+	last := layout.Chat.Msgs[len(layout.Chat.Msgs)-1]
+	if last.Content != "Retention policy: nothing to remove" {
+		t.Errorf("Expected the report in the transcript, got %v", last)
+	}
+}
+
+func TestPinCmd(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	model, _ := PinCmd{title: "/pin"}.Execute(layout)
+	layout = model.(LayoutView)
+
+	session, _ := sqliteDB.LoadSession(layout.Chat.Session.ID)
+	if !layout.Chat.Session.Pinned || !session.Pinned || lastMsg(layout).Content != "Session pinned" {
+		t.Fatalf("Expected the session pinned")
+	}
+
+	layout.Storage = pinFailStorage{sqliteDB}
+	model, _ = PinCmd{title: "/pin"}.Execute(layout)
+	layout = model.(LayoutView)
+
+	if !layout.Chat.Session.Pinned || lastMsg(layout).Role != schema.ErrMsg {
+		t.Fatalf("Expected the session to stay pinned and the error reported, got %q", lastMsg(layout).Content)
+	}
+}
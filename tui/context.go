@@ -0,0 +1,152 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// CmdContext runs context commands against the layout, collecting their
+// changes and the commands they start.
+type CmdContext struct {
+	layout LayoutView
+	args   schema.Args
+	cmds   []tea.Cmd
+}
+
+// ContextMsg carries the function returned by the work started with Go.
+type ContextMsg struct {
+	Done func(ctx schema.CmdContext)
+}
+
+// RunContext runs the function with a context on the layout and returns
+// the layout as it left it.
+func RunContext(layout LayoutView, args schema.Args, run func(ctx schema.CmdContext)) (LayoutView, tea.Cmd) {
+	ctx := &CmdContext{layout: layout, args: args}
+	run(ctx)
+
+	return ctx.layout, tea.Batch(ctx.cmds...)
+}
+
+func (ctx *CmdContext) Session() schema.ChatSession {
+	session := ctx.layout.Chat.Session
+	session.Msgs = ctx.layout.Chat.Msgs
+
+	return session
+}
+
+func (ctx *CmdContext) Provider() schema.ChatProvider    { return ctx.layout.Chat.Provider }
+func (ctx *CmdContext) Providers() []schema.ChatProvider { return ctx.layout.Providers }
+func (ctx *CmdContext) Args() schema.Args                { return ctx.args }
+
+func (ctx *CmdContext) Send(prompt string) {
+	var cmd tea.Cmd
+	ctx.layout.Chat, cmd = ctx.layout.Chat.Send(prompt)
+	ctx.layout.Sidebar.Running[ctx.layout.Chat.Session.ID] = true
+
+	ctx.cmds = append(ctx.cmds, cmd)
+}
+
+func (ctx *CmdContext) AddMsg(role schema.MsgRole, content string) {
+	ctx.layout = ctx.layout.AddMsg(role, content)
+}
+
+func (ctx *CmdContext) SetInput(text string) {
+	ctx.layout.Chat.Input.SetValue(text)
+}
+
+func (ctx *CmdContext) SwitchProvider(name string) error {
+	provider := ctx.layout.findProvider(name)
+	if provider == nil {
+		return fmt.Errorf("Error switching provider: no provider named %q", name)
+	}
+
+	ctx.layout = ctx.layout.SwitchProvider(provider)
+
+	return nil
+}
+
+func (ctx *CmdContext) OpenMenu(items []list.Item) {
+	ctx.layout.Menu = ctx.layout.Menu.PushMenu(ContextItems(items))
+	ctx.layout.Chat.Input.SetValue("/")
+	ctx.layout = ctx.layout.SyncMenu()
+}
+
+func (ctx *CmdContext) Prompt(label string, done func(ctx schema.CmdContext, value string)) {
+	ctx.layout.Prompt = NewTextPrompt(label, func(layout LayoutView, value string) (LayoutView, tea.Cmd) {
+		return RunContext(layout, ctx.args, func(ctx schema.CmdContext) {
+			done(ctx, value)
+		})
+	})
+}
+
+func (ctx *CmdContext) Confirm(question string, done func(ctx schema.CmdContext)) {
+	ctx.OpenMenu([]list.Item{
+		schema.Cmd{Name: "/yes", Desc: question, Action: done},
+		schema.Cmd{Name: "/no", Desc: "Cancel"},
+	})
+}
+
+func (ctx *CmdContext) Go(work func() func(ctx schema.CmdContext)) {
+	ctx.cmds = append(ctx.cmds, func() tea.Msg {
+		return ContextMsg{Done: work()}
+	})
+}
+
+// HandleContext runs the function returned by background work.
+func (layout LayoutView) HandleContext(msg ContextMsg) (LayoutView, tea.Cmd) {
+	if msg.Done == nil {
+		return layout, nil
+	}
+
+	return RunContext(layout, schema.Args{Words: []string{}}, msg.Done)
+}
+
+// ContextItem is the menu command of a context command.
+type ContextItem struct {
+	schema.ContextCmd
+}
+
+func (item ContextItem) Usage() string {
+	if cmd, ok := item.ContextCmd.(interface{ Usage() string }); ok {
+		return cmd.Usage()
+	}
+
+	return ""
+}
+func (item ContextItem) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return item.ExecuteArgs(model, cmdArgs(model))
+}
+func (item ContextItem) ExecuteArgs(model tea.Model, args schema.Args) (tea.Model, tea.Cmd) {
+	layout := model.(LayoutView)
+
+	layout.Menu = layout.Menu.Close()
+	layout.Chat.Input.SetValue("")
+
+	return RunContext(layout, args, item.Run)
+}
+func (item ContextItem) Complete(model tea.Model, args schema.Args) []string {
+	completer, ok := item.ContextCmd.(schema.ContextCompleter)
+	if !ok {
+		return []string{}
+	}
+
+	return completer.Complete(&CmdContext{layout: model.(LayoutView), args: args}, args)
+}
+
+// ContextItems makes menu commands of the context commands among the items.
+func ContextItems(items []list.Item) []list.Item {
+	wrapped := make([]list.Item, len(items))
+	for i, item := range items {
+		_, isCmd := item.(schema.CmdItem)
+		if cmd, ok := item.(schema.ContextCmd); ok && !isCmd {
+			item = ContextItem{cmd}
+		}
+
+		wrapped[i] = item
+	}
+
+	return wrapped
+}
@@ -0,0 +1,165 @@
+package tui
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// runCmd types the prompt and runs the command it names from the menu.
+func runCmd(t *testing.T, layout LayoutView, prompt string) (LayoutView, tea.Cmd) {
+	layout = typePrompt(layout, prompt)
+
+	cmd := layout.Menu.Command(schema.ParseArgs(prompt).Name)
+	if cmd == nil {
+		t.Fatalf("Expected %q to name a command", prompt)
+	}
+
+	model, teaCmd := layout.ExecuteCmd(cmd)
+
+	return model.(LayoutView), teaCmd
+}
+
+func lastMsg(layout LayoutView) schema.Msg {
+	return layout.Chat.Msgs[len(layout.Chat.Msgs)-1]
+}
+
+func TestContextCmd(t *testing.T) {
+	note := schema.Cmd{Name: "/note", Desc: "Add a note", Args: "<text>", Action: func(ctx schema.CmdContext) {
+		session := ctx.Session()
+		ctx.AddMsg(schema.InternalMsg, fmt.Sprintf("%s (%d): %s", session.Title, len(session.Msgs), ctx.Args().Text()))
+		ctx.SetInput("next")
+	}}
+
+	layout, _ := newTestLayout(t, note)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{2: "a note"})
+
+	// The argument is required
+	layout, _ = runCmd(t, layout, "/note")
+	if layout.Chat.Input.Value() != "/note " || len(layout.Chat.Msgs) != 5 {
+		t.Fatalf("Expected the command to wait for its argument, got %q", layout.Chat.Input.Value())
+	}
+
+	if layout.Info != "usage: /note <text>" {
+		t.Fatalf("Expected the usage of the command, got %q", layout.Info)
+	}
+
+	layout, _ = runCmd(t, layout, "/note Bob's idea")
+
+	msg := lastMsg(layout)
+	if msg.Role != schema.InternalMsg || msg.Content != "Test session (5): Bob's idea" {
+		t.Fatalf("Expected the note with the session and arguments, got %q", msg.Content)
+	}
+
+	if layout.Chat.Input.Value() != "next" || layout.Menu.Active {
+		t.Fatalf("Expected the input to be set and the menu closed, got %q", layout.Chat.Input.Value())
+	}
+}
+
+func TestContextSwitchProvider(t *testing.T) {
+	var switchErr error
+	use := schema.Cmd{Name: "/use", Args: "<provider>", Action: func(ctx schema.CmdContext) {
+		switchErr = ctx.SwitchProvider(ctx.Args().Get(0))
+		if switchErr != nil {
+			ctx.AddMsg(schema.ErrMsg, switchErr.Error())
+		}
+	}}
+
+	layout, _ := newTestLayout(t, use)
+
+	layout, _ = runCmd(t, layout, "/use second")
+	if switchErr != nil || layout.Chat.Provider.Name() != "second" {
+		t.Fatalf("Expected to switch to the second provider, got %q: %v", layout.Chat.Provider.Name(), switchErr)
+	}
+
+	layout, _ = runCmd(t, layout, "/use missing")
+	if switchErr == nil || layout.Chat.Provider.Name() != "second" || lastMsg(layout).Role != schema.ErrMsg {
+		t.Fatalf("Expected an error for a missing provider")
+	}
+}
+
+func TestContextConfirm(t *testing.T) {
+	wipe := schema.Cmd{Name: "/wipe", Action: func(ctx schema.CmdContext) {
+		ctx.Confirm("Wipe the notes?", func(ctx schema.CmdContext) {
+			ctx.AddMsg(schema.InternalMsg, "Wiped")
+		})
+	}}
+
+	layout, _ := newTestLayout(t, wipe)
+
+	layout, _ = runCmd(t, layout, "/wipe")
+	if !layout.Menu.Active || !layout.Menu.Submenu || layout.Chat.Input.Value() != "/" {
+		t.Fatalf("Expected the confirmation submenu to open")
+	}
+
+	no := layout
+	no, _ = runCmd(t, no, "/no")
+	if len(no.Chat.Msgs) != 0 || no.Menu.Active {
+		t.Fatalf("Expected /no to close the menu without running the action")
+	}
+
+	layout, _ = runCmd(t, layout, "/yes")
+	if len(layout.Chat.Msgs) != 1 || lastMsg(layout).Content != "Wiped" || layout.Menu.Active {
+		t.Fatalf("Expected /yes to run the action and close the menu")
+	}
+}
+
+func TestContextPrompt(t *testing.T) {
+	greet := schema.Cmd{Name: "/greet", Action: func(ctx schema.CmdContext) {
+		ctx.Prompt("Your name", func(ctx schema.CmdContext, value string) {
+			ctx.AddMsg(schema.InternalMsg, "Hello "+value)
+		})
+	}}
+
+	layout, _ := newTestLayout(t, greet)
+
+	layout, _ = runCmd(t, layout, "/greet")
+	if !layout.Prompt.Active || layout.Prompt.Label != "Your name" {
+		t.Fatalf("Expected the prompt to open")
+	}
+
+	if layout.Prompt.Input.EchoMode != textinput.EchoNormal {
+		t.Fatalf("Expected the text to be shown as typed")
+	}
+
+	layout.Prompt.Input.SetValue("Bob")
+	model, _ := layout.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	layout = model.(LayoutView)
+
+	if layout.Prompt.Active || lastMsg(layout).Content != "Hello Bob" {
+		t.Fatalf("Expected the prompt to close and greet, got %v", layout.Chat.Msgs)
+	}
+}
+
+func TestContextGo(t *testing.T) {
+	fetch := schema.Cmd{Name: "/fetch", Action: func(ctx schema.CmdContext) {
+		ctx.AddMsg(schema.InternalMsg, "Fetching")
+		ctx.Go(func() func(ctx schema.CmdContext) {
+			return func(ctx schema.CmdContext) {
+				ctx.AddMsg(schema.InternalMsg, "Fetched")
+			}
+		})
+	}}
+
+	layout, _ := newTestLayout(t, fetch)
+
+	layout, cmd := runCmd(t, layout, "/fetch")
+	if len(layout.Chat.Msgs) != 1 || cmd == nil {
+		t.Fatalf("Expected the work to be started after the first note")
+	}
+
+	msg, ok := cmd().(ContextMsg)
+	if !ok {
+		t.Fatalf("Expected the work to return a ContextMsg")
+	}
+
+	model, _ := layout.Update(msg)
+	layout = model.(LayoutView)
+
+	if len(layout.Chat.Msgs) != 2 || lastMsg(layout).Content != "Fetched" {
+		t.Fatalf("Expected the returned function to run on the layout, got %v", layout.Chat.Msgs)
+	}
+}
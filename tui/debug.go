@@ -0,0 +1,233 @@
+package tui
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+const (
+	// debugEventLimit is the number of provider events kept in the panel
+	debugEventLimit = 200
+
+	// debugLogTail is how much of the end of the debug log is shown
+	debugLogTail = 16 * 1024
+
+	// debugPayloadLimit cuts long request payloads and chunks
+	debugPayloadLimit = 4000
+)
+
+// DebugPanel shows the raw events of the provider, statistics of the last
+// run and the end of the debug log.
+type DebugPanel struct {
+	Events   []schema.DebugEvent
+	Run      RunStats
+	LogPath  string
+	Log      string
+	Viewport *viewport.Model
+
+	// Tick is the generation of the log refresh, ticks of an earlier
+	// visit to the debug mode are dropped.
+	Tick int
+}
+
+// RunStats are the timings and chunk count of a provider run.
+type RunStats struct {
+	Provider   string
+	Start      time.Time
+	FirstChunk time.Duration
+	Duration   time.Duration
+	Chunks     int
+	Done       bool
+}
+
+type debugTickMsg struct {
+	tick int
+}
+
+func NewDebugPanel(logPath string) DebugPanel {
+	view := viewport.New(0, 0)
+	view.KeyMap = viewport.KeyMap{
+		PageDown: key.NewBinding(key.WithKeys("pgdown")),
+		PageUp:   key.NewBinding(key.WithKeys("pgup")),
+	}
+
+	return DebugPanel{
+		Events:   []schema.DebugEvent{},
+		LogPath:  logPath,
+		Viewport: &view,
+	}
+}
+
+// Add records the event and updates the statistics of the run.
+func (panel DebugPanel) Add(event schema.DebugEvent) DebugPanel {
+	switch event.Kind {
+	case schema.DebugRequest:
+		panel.Run = RunStats{Provider: event.Provider, Start: event.Time}
+	case schema.DebugChunk:
+		if panel.Run.Chunks == 0 {
+			panel.Run.FirstChunk = event.Time.Sub(panel.Run.Start)
+		}
+
+		panel.Run.Chunks++
+	case schema.DebugResponse, schema.DebugError:
+		panel.Run.Duration = event.Time.Sub(panel.Run.Start)
+		panel.Run.Done = true
+	}
+
+	// Consecutive chunks are shown as one event with the streamed text
+	last := len(panel.Events) - 1
+	if event.Kind == schema.DebugChunk && last >= 0 && panel.Events[last].Kind == schema.DebugChunk {
+		panel.Events[last].Text += event.Text
+		return panel
+	}
+
+	panel.Events = append(panel.Events, event)
+	if len(panel.Events) > debugEventLimit {
+		panel.Events = panel.Events[len(panel.Events)-debugEventLimit:]
+	}
+
+	return panel
+}
+
+// ReadLog loads the end of the debug log.
+func (panel DebugPanel) ReadLog() DebugPanel {
+	if panel.LogPath == "" {
+		return panel
+	}
+
+	file, err := os.Open(panel.LogPath)
+	if err != nil {
+		panel.Log = err.Error()
+		return panel
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err == nil && info.Size() > debugLogTail {
+		file.Seek(-debugLogTail, io.SeekEnd)
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		panel.Log = err.Error()
+		return panel
+	}
+
+	panel.Log = strings.TrimRight(string(data), "\n")
+
+	return panel
+}
+
+func (panel DebugPanel) Render(width int, style schema.LayoutStyle) string {
+	text := style.Debug.Text.Width(width)
+	lines := []string{style.Debug.Section.Render("Last run")}
+
+	run := panel.Run
+	if run.Start.IsZero() {
+		lines = append(lines, style.Debug.Label.Render("No provider events yet, only providers implementing schema.DebugProvider report them"))
+	} else {
+		duration := "running"
+		if run.Done {
+			duration = run.Duration.Round(time.Millisecond).String()
+		}
+
+		lines = append(lines, text.Render(fmt.Sprintf(
+			"%s | started %s | first chunk %s | total %s | %d chunks",
+			run.Provider,
+			run.Start.Format("15:04:05"),
+			run.FirstChunk.Round(time.Millisecond),
+			duration,
+			run.Chunks,
+		)))
+	}
+
+	lines = append(lines, style.Debug.Section.Render(fmt.Sprintf("Events (%d)", len(panel.Events))))
+	for _, event := range panel.Events {
+		payload := event.Text
+		if runes := []rune(payload); len(runes) > debugPayloadLimit {
+			payload = string(runes[:debugPayloadLimit]) + "…"
+		}
+
+		label := style.Debug.Label.Render(fmt.Sprintf("%s %-8s ", event.Time.Format("15:04:05.000"), event.Kind))
+		lines = append(lines, label+text.Width(width-lipgloss.Width(label)).Render(payload))
+	}
+
+	if panel.LogPath != "" {
+		lines = append(lines, style.Debug.Section.Render(panel.LogPath))
+		lines = append(lines, text.Render(panel.Log))
+	}
+
+	return lipgloss.JoinVertical(lipgloss.Left, lines...)
+}
+
+// View renders the panel in place of the transcript, following new events
+// while it's scrolled to the bottom.
+func (panel DebugPanel) View(width int, height int, style schema.LayoutStyle) string {
+	atBottom := panel.Viewport.AtBottom() || panel.Viewport.Height == 0
+
+	panel.Viewport.Width = width - 6
+	panel.Viewport.Height = height
+	panel.Viewport.SetContent(panel.Render(width-6, style))
+
+	if atBottom {
+		panel.Viewport.GotoBottom()
+	}
+
+	return lipgloss.PlaceHorizontal(
+		width,
+		lipgloss.Center,
+		style.Chat.ContentView.Render(panel.Viewport.View()),
+		lipgloss.WithWhitespaceBackground(lipgloss.Color(style.WhitespaceBGcolor)),
+	)
+}
+
+// ScheduleDebug refreshes the debug log while the debug mode is shown.
+func (layout LayoutView) ScheduleDebug() tea.Cmd {
+	tick := layout.Debug.Tick
+
+	return tea.Tick(time.Second, func(time.Time) tea.Msg {
+		return debugTickMsg{tick: tick}
+	})
+}
+
+// HandleDebugTick refreshes the log and schedules the next refresh, unless
+// the tick is from an earlier visit to the debug mode.
+func (layout LayoutView) HandleDebugTick(msg debugTickMsg) (LayoutView, tea.Cmd) {
+	if layout.Mode != schema.Debug || msg.tick != layout.Debug.Tick {
+		return layout, nil
+	}
+
+	layout.Debug = layout.Debug.ReadLog()
+
+	return layout, layout.ScheduleDebug()
+}
+
+// NextMode switches to the next mode.
+func (layout LayoutView) NextMode() (LayoutView, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch layout.Mode {
+	case schema.Chat:
+		layout.Mode = schema.Debug
+		layout.Debug = layout.Debug.ReadLog()
+		layout.Debug.Tick++
+		cmd = layout.ScheduleDebug()
+	case schema.Debug:
+		layout = layout.EnterAction()
+	default:
+		return layout.LeaveAction()
+	}
+
+	layout.Info = layout.ChatInfo()
+
+	return layout, cmd
+}
@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestDebugPanelAdd(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	at := func(ms int) time.Time { return start.Add(time.Duration(ms) * time.Millisecond) }
+
+	tests := []struct {
+		name   string
+		events []schema.DebugEvent
+		run    RunStats
+		texts  []string
+	}{
+		{
+			name:   "request",
+			events: []schema.DebugEvent{{Time: at(0), Provider: "first", Kind: schema.DebugRequest, Text: "{}"}},
+			run:    RunStats{Provider: "first", Start: at(0)},
+			texts:  []string{"{}"},
+		},
+		{
+			name: "streamed run",
+			events: []schema.DebugEvent{
+				{Time: at(0), Provider: "first", Kind: schema.DebugRequest, Text: "{}"},
+				{Time: at(150), Kind: schema.DebugChunk, Text: "Hel"},
+				{Time: at(200), Kind: schema.DebugChunk, Text: "lo"},
+				{Time: at(900), Kind: schema.DebugResponse, Text: "done"},
+			},
+			run:   RunStats{Provider: "first", Start: at(0), FirstChunk: 150 * time.Millisecond, Duration: 900 * time.Millisecond, Chunks: 2, Done: true},
+			texts: []string{"{}", "Hello", "done"},
+		},
+		{
+			name: "error",
+			events: []schema.DebugEvent{
+				{Time: at(0), Provider: "first", Kind: schema.DebugRequest},
+				{Time: at(300), Kind: schema.DebugError, Text: "timeout"},
+			},
+			run:   RunStats{Provider: "first", Start: at(0), Duration: 300 * time.Millisecond, Done: true},
+			texts: []string{"", "timeout"},
+		},
+		{
+			name: "new request resets the run",
+			events: []schema.DebugEvent{
+				{Time: at(0), Provider: "first", Kind: schema.DebugRequest},
+				{Time: at(100), Kind: schema.DebugChunk, Text: "a"},
+				{Time: at(200), Kind: schema.DebugResponse},
+				{Time: at(1000), Provider: "second", Kind: schema.DebugRequest},
+				{Time: at(1050), Kind: schema.DebugChunk, Text: "b"},
+			},
+			run:   RunStats{Provider: "second", Start: at(1000), FirstChunk: 50 * time.Millisecond, Chunks: 1},
+			texts: []string{"", "a", "", "", "b"},
+		},
+	}
+
+	for _, test := range tests {
+		panel := NewDebugPanel("")
+		for _, event := range test.events {
+			panel = panel.Add(event)
+		}
+
+		if panel.Run != test.run {
+			t.Errorf("%s: expected run %+v, got %+v", test.name, test.run, panel.Run)
+		}
+
+		texts := []string{}
+		for _, event := range panel.Events {
+			texts = append(texts, event.Text)
+		}
+
+		if !slices.Equal(texts, test.texts) {
+			t.Errorf("%s: expected events %q, got %q", test.name, test.texts, texts)
+		}
+	}
+}
+
+func TestDebugPanelLimit(t *testing.T) {
+	panel := NewDebugPanel("")
+	for i := 0; i < debugEventLimit+10; i++ {
+		panel = panel.Add(schema.DebugEvent{Kind: schema.DebugRequest, Text: string(rune('a' + i%26))})
+	}
+
+	if len(panel.Events) != debugEventLimit {
+		t.Fatalf("Expected %d events kept, got %d", debugEventLimit, len(panel.Events))
+	}
+
+	if panel.Events[0].Text != string(rune('a'+10%26)) {
+		t.Fatalf("Expected the oldest events dropped, got %q first", panel.Events[0].Text)
+	}
+}
+
+func TestDebugTicks(t *testing.T) {
+	layout, _ := newTestLayout(t)
+
+	layout, cmd := layout.NextMode()
+	if layout.Mode != schema.Debug || cmd == nil {
+		t.Fatalf("Expected the debug mode to schedule a refresh")
+	}
+
+	stale := debugTickMsg{tick: layout.Debug.Tick}
+
+	// Leave and come back to the debug mode within a second
+	layout, _ = layout.NextMode()
+	layout, _ = layout.NextMode()
+	layout, _ = layout.NextMode()
+	if layout.Mode != schema.Debug {
+		t.Fatalf("Expected the debug mode again, got %v", layout.Mode)
+	}
+
+	_, cmd = layout.HandleDebugTick(stale)
+	if cmd != nil {
+		t.Fatalf("Expected the tick of the earlier visit to be dropped")
+	}
+
+	_, cmd = layout.HandleDebugTick(debugTickMsg{tick: layout.Debug.Tick})
+	if cmd == nil {
+		t.Fatalf("Expected the current tick to schedule the next refresh")
+	}
+
+	layout, _ = layout.NextMode()
+	_, cmd = layout.HandleDebugTick(debugTickMsg{tick: layout.Debug.Tick})
+	if cmd != nil {
+		t.Fatalf("Expected no refresh outside the debug mode")
+	}
+}
@@ -0,0 +1,88 @@
+package tui
+
+import (
+	"fmt"
+	"log"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/export"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// DatasetMsg reports the outcome of exporting the rated answers.
+type DatasetMsg struct {
+	Path  string
+	Count int
+	Err   error
+}
+
+// RateMsg rates the message at the index, rating it the same again removes
+// the rating.
+func (layout LayoutView) RateMsg(index int, rating string, note string) LayoutView {
+	if index < 0 || index >= len(layout.Chat.Msgs) {
+		return layout
+	}
+
+	msg := layout.Chat.Msgs[index]
+	if msg.ID == "" || layout.Storage == nil {
+		return layout.AddMsg(schema.ErrMsg, "Only saved answers can be rated")
+	}
+
+	meta := schema.MsgMeta{schema.MetaRating: rating, schema.MetaRatingNote: note}
+	if msg.Meta[schema.MetaRating] == rating && note == "" {
+		meta = schema.MsgMeta{schema.MetaRating: "", schema.MetaRatingNote: ""}
+	}
+
+	storage, ok := layout.Storage.(schema.EditableStorage)
+	if !ok {
+		return layout.AddMsg(schema.ErrMsg, "The storage doesn't support rating messages")
+	}
+
+	err := storage.AnnotateMsg(layout.Chat.Session.ID, msg.ID, meta)
+	if err != nil {
+		log.Printf("Error while rating message: %s", err)
+		return layout.AddMsg(schema.ErrMsg, err.Error())
+	}
+
+	updated := schema.MsgMeta{}
+	for key, value := range msg.Meta {
+		updated[key] = value
+	}
+
+	for key, value := range meta {
+		if value == "" {
+			delete(updated, key)
+		} else {
+			updated[key] = value
+		}
+	}
+
+	msg.Meta = updated
+	layout.Chat.Msgs[index] = msg
+
+	if meta[schema.MetaRating] == "" {
+		return layout.AddMsg(schema.InternalMsg, "Rating removed")
+	}
+
+	return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Answer rated %s", rating))
+}
+
+// ExportDataset writes the rated answers of all sessions to a JSONL file in
+// the background.
+func (layout LayoutView) ExportDataset(path string) tea.Cmd {
+	storage := layout.Storage
+
+	return func() tea.Msg {
+		count, err := export.DatasetToFile(storage.ListSessions(), path)
+		return DatasetMsg{Path: path, Count: count, Err: err}
+	}
+}
+
+func (layout LayoutView) HandleDataset(msg DatasetMsg) LayoutView {
+	if msg.Err != nil {
+		log.Printf("Error while exporting dataset: %s", msg.Err)
+		return layout.AddMsg(schema.ErrMsg, msg.Err.Error())
+	}
+
+	return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Exported %d rated answers to %s", msg.Count, msg.Path))
+}
@@ -0,0 +1,86 @@
+package tui
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestRateMsg(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+
+	msgs := testMsgs()
+	msgs[1].Meta = schema.MsgMeta{schema.MetaProvider: "first"}
+	layout = openTestSession(t, layout, msgs, map[int]string{1: "A note"})
+
+	// The first answer is at index 2 behind the note
+	tests := []struct {
+		rating string
+		note   string
+		meta   schema.MsgMeta
+		info   string
+	}{
+		{
+			rating: schema.RatingGood,
+			meta:   schema.MsgMeta{schema.MetaProvider: "first", schema.MetaRating: schema.RatingGood},
+			info:   "Answer rated good",
+		},
+		{
+			rating: schema.RatingBad,
+			note:   "made up",
+			meta:   schema.MsgMeta{schema.MetaProvider: "first", schema.MetaRating: schema.RatingBad, schema.MetaRatingNote: "made up"},
+			info:   "Answer rated bad",
+		},
+		{
+			// A new note keeps the rating
+			rating: schema.RatingBad,
+			note:   "wrong API",
+			meta:   schema.MsgMeta{schema.MetaProvider: "first", schema.MetaRating: schema.RatingBad, schema.MetaRatingNote: "wrong API"},
+			info:   "Answer rated bad",
+		},
+		{
+			// Rating it the same again removes the rating and its note
+			rating: schema.RatingBad,
+			meta:   schema.MsgMeta{schema.MetaProvider: "first"},
+			info:   "Rating removed",
+		},
+	}
+
+	for _, test := range tests {
+		layout = layout.RateMsg(2, test.rating, test.note)
+
+		if lastMsg(layout).Content != test.info {
+			t.Fatalf("Expected %q, got %q", test.info, lastMsg(layout).Content)
+		}
+
+		if !maps.Equal(layout.Chat.Msgs[2].Meta, test.meta) {
+			t.Fatalf("Expected the shown meta %v, got %v", test.meta, layout.Chat.Msgs[2].Meta)
+		}
+
+		stored, err := sqliteDB.LoadSession(layout.Chat.Session.ID)
+		if err != nil {
+			t.Fatalf("Failed to load session: %v", err)
+		}
+
+		if !maps.Equal(stored.Msgs[1].Meta, test.meta) {
+			t.Fatalf("Expected the stored meta %v, got %v", test.meta, stored.Msgs[1].Meta)
+		}
+	}
+}
+
+func TestRateUnsavedMsg(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout = openTestSession(t, layout, testMsgs(), map[int]string{1: "A note"})
+
+	layout = layout.RateMsg(1, schema.RatingGood, "")
+	if lastMsg(layout).Role != schema.ErrMsg || layout.Chat.Msgs[1].Meta != nil {
+		t.Fatalf("Expected notes not to be rated, got %q", lastMsg(layout).Content)
+	}
+
+	count := len(layout.Chat.Msgs)
+	layout = layout.RateMsg(count, schema.RatingGood, "")
+	if len(layout.Chat.Msgs) != count {
+		t.Fatalf("Expected an index past the transcript to be ignored")
+	}
+}
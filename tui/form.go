@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/templates"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// TemplateForm asks for the variables of a prompt template, shown in place
+// of the chat input.
+type TemplateForm struct {
+	Active   bool
+	Template templates.Template
+	Inputs   []textinput.Model
+	Focus    int
+}
+
+func NewTemplateForm(template templates.Template) TemplateForm {
+	inputs := []textinput.Model{}
+	for _, name := range template.Vars {
+		input := textinput.New()
+		input.Prompt = name + ": "
+		input.Placeholder = name
+		inputs = append(inputs, input)
+	}
+
+	if len(inputs) > 0 {
+		inputs[0].Focus()
+	}
+
+	return TemplateForm{
+		Active:   true,
+		Template: template,
+		Inputs:   inputs,
+	}
+}
+
+// Height is the number of lines of the form, like the input height it
+// excludes the borders.
+func (form TemplateForm) Height() int {
+	return len(form.Inputs) + 1
+}
+
+func (form TemplateForm) View(width int, style schema.LayoutStyle) string {
+	lines := []string{fmt.Sprintf("%s - tab next field | enter insert | esc cancel", form.Template.Name)}
+	for _, input := range form.Inputs {
+		input.Width = width - 10 - len(input.Prompt)
+		lines = append(lines, input.View())
+	}
+
+	return lipgloss.PlaceHorizontal(
+		width,
+		lipgloss.Center,
+		style.Chat.Input.Width(width-4).Render(lipgloss.JoinVertical(lipgloss.Left, lines...)),
+		lipgloss.WithWhitespaceBackground(lipgloss.Color(style.WhitespaceBGcolor)),
+	)
+}
+
+// Values returns the entered value of every variable.
+func (form TemplateForm) Values() map[string]string {
+	values := map[string]string{}
+	for i, name := range form.Template.Vars {
+		values[name] = form.Inputs[i].Value()
+	}
+
+	return values
+}
+
+func (form TemplateForm) focus(index int) TemplateForm {
+	if len(form.Inputs) == 0 {
+		return form
+	}
+
+	form.Inputs[form.Focus].Blur()
+	form.Focus = (index + len(form.Inputs)) % len(form.Inputs)
+	form.Inputs[form.Focus].Focus()
+
+	return form
+}
+
+// UpdateForm handles key presses while the template form is open.
+func (layout LayoutView) UpdateForm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	form := layout.Form
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		layout.Form = TemplateForm{}
+		return layout, nil
+	case tea.KeyTab, tea.KeyDown:
+		layout.Form = form.focus(form.Focus + 1)
+		return layout, nil
+	case tea.KeyShiftTab, tea.KeyUp:
+		layout.Form = form.focus(form.Focus - 1)
+		return layout, nil
+	case tea.KeyEnter:
+		if form.Focus < len(form.Inputs)-1 {
+			layout.Form = form.focus(form.Focus + 1)
+			return layout, nil
+		}
+
+		// The expanded prompt goes to the input for a last edit before sending
+		layout.Chat.Input.SetValue(form.Template.Expand(form.Values()))
+		layout.Form = TemplateForm{}
+
+		return layout, nil
+	}
+
+	if len(form.Inputs) == 0 {
+		return layout, nil
+	}
+
+	input, cmd := form.Inputs[form.Focus].Update(msg)
+	layout.Form.Inputs[form.Focus] = input
+
+	return layout, cmd
+}
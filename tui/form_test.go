@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/templates"
+)
+
+func pressForm(t *testing.T, layout LayoutView, msg tea.KeyMsg) LayoutView {
+	model, _ := layout.UpdateForm(msg)
+	return model.(LayoutView)
+}
+
+func typeForm(t *testing.T, layout LayoutView, text string) LayoutView {
+	return pressForm(t, layout, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(text)})
+}
+
+func TestTemplateFormFocus(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout.Form = NewTemplateForm(templates.Parse("review", "Review {{lang}} for {{focus}} in {{file}}"))
+
+	if layout.Form.Focus != 0 || !layout.Form.Inputs[0].Focused() {
+		t.Fatalf("Expected the first field focused")
+	}
+
+	tests := []struct {
+		key   tea.KeyType
+		focus int
+	}{
+		{tea.KeyTab, 1},
+		{tea.KeyDown, 2},
+		{tea.KeyTab, 0},
+		{tea.KeyShiftTab, 2},
+		{tea.KeyUp, 1},
+		{tea.KeyUp, 0},
+	}
+
+	for _, test := range tests {
+		layout = pressForm(t, layout, tea.KeyMsg{Type: test.key})
+
+		if layout.Form.Focus != test.focus {
+			t.Fatalf("Expected %s to focus field %d, got %d", test.key, test.focus, layout.Form.Focus)
+		}
+
+		for i, input := range layout.Form.Inputs {
+			if input.Focused() != (i == test.focus) {
+				t.Fatalf("Expected only field %d focused after %s", test.focus, test.key)
+			}
+		}
+	}
+}
+
+func TestTemplateFormEnter(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout.Form = NewTemplateForm(templates.Parse("review", "Review {{lang}} for {{focus}}"))
+
+	layout = typeForm(t, layout, "Go")
+	layout = pressForm(t, layout, tea.KeyMsg{Type: tea.KeyEnter})
+
+	// Enter moves on until the last field
+	if !layout.Form.Active || layout.Form.Focus != 1 || layout.Chat.Input.Value() != "" {
+		t.Fatalf("Expected Enter to move to the next field")
+	}
+
+	layout = typeForm(t, layout, "races")
+	layout = pressForm(t, layout, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if layout.Form.Active || layout.Chat.Input.Value() != "Review Go for races" {
+		t.Fatalf("Expected the expanded prompt in the input, got %q", layout.Chat.Input.Value())
+	}
+}
+
+func TestTemplateFormCancel(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout.Form = NewTemplateForm(templates.Parse("review", "Review {{lang}}"))
+
+	layout = typeForm(t, layout, "Go")
+	layout = pressForm(t, layout, tea.KeyMsg{Type: tea.KeyEsc})
+
+	if layout.Form.Active || layout.Chat.Input.Value() != "" {
+		t.Fatalf("Expected Esc to close the form without a prompt")
+	}
+}
+
+func TestTemplateFormWithoutVars(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	layout.Form = NewTemplateForm(templates.Parse("plain", "Summarize the session"))
+
+	layout = pressForm(t, layout, tea.KeyMsg{Type: tea.KeyTab})
+	layout = typeForm(t, layout, "x")
+	layout = pressForm(t, layout, tea.KeyMsg{Type: tea.KeyEnter})
+
+	if layout.Form.Active || layout.Chat.Input.Value() != "Summarize the session" {
+		t.Fatalf("Expected the body in the input, got %q", layout.Chat.Input.Value())
+	}
+}
@@ -1,12 +1,17 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"log"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/export"
 	"github.com/struki84/clipt/tui/chat"
 	"github.com/struki84/clipt/tui/menu"
 	"github.com/struki84/clipt/tui/schema"
@@ -20,31 +25,100 @@ type LayoutView struct {
 	Menu  menu.ChatMenu
 	Chat  chat.ChatView
 
-	Storage   schema.SessionStorage
-	Providers []schema.ChatProvider
+	Storage     schema.SessionStorage
+	Providers   []schema.ChatProvider
+	AutoTitle   schema.SessionTitler
+	Retention   schema.RetentionPolicy
+	TemplateDir string
 
 	Info   string
 	Status string
 	Mode   schema.Mode
+
+	SessionPages SessionPages
+	Prompt       Prompt
+	Form         TemplateForm
+	Debug        DebugPanel
+	Sidebar      Sidebar
+	Changes      <-chan schema.ChangeEvent
+	Output       *Output
+
+	unsubscribe func()
+}
+
+// SessionPages tracks the paginated loading of the sessions submenu.
+type SessionPages struct {
+	Active  bool
+	Loading bool
+	Done    bool
+	Offset  int
+}
+
+// SessionPageSize is the number of sessions loaded per page.
+const SessionPageSize = 50
+
+// SessionPageMsg carries a page of session summaries loaded in the background.
+type SessionPageMsg struct {
+	Offset   int
+	Sessions []schema.SessionSummary
+	Err      error
 }
 
 func NewLayout(conf schema.Config) LayoutView {
 	layout := LayoutView{
-		Menu:      menu.New(conf.Cmds, conf.Style),
-		Chat:      chat.New(conf.Providers[0], conf.Style),
-		Style:     conf.Style,
-		Storage:   conf.Storage,
-		Providers: conf.Providers,
-		Info:      "enter - send | \"/\" - menu",
-		Mode:      schema.Chat,
+		Menu:        menu.New(ContextItems(conf.Cmds), conf.Style),
+		Chat:        chat.New(conf.Providers[0], conf.Style),
+		Style:       conf.Style,
+		Storage:     conf.Storage,
+		Providers:   conf.Providers,
+		AutoTitle:   conf.AutoTitle,
+		Retention:   conf.Retention,
+		TemplateDir: conf.TemplateDir,
+		Mode:        schema.Chat,
+		Output:      NewOutput(os.Stdout),
 	}
 
-	sessionID := randstr.String(8)
+	layout.Chat.Session = newSession()
 	layout.Chat.Msgs = []schema.Msg{}
-	layout.Chat.Session = schema.ChatSession{
-		ID:        sessionID,
-		Title:     fmt.Sprintf("Session - %s", sessionID),
-		CreatedAt: time.Now().Unix(),
+	layout.Chat.History = chat.NewHistory(conf.History.Path, conf.History.Size)
+	layout.Sidebar = NewSidebar(conf.Sidebar)
+
+	if conf.Debug.Log {
+		layout.Debug = NewDebugPanel(conf.Debug.Path)
+	} else {
+		layout.Debug = NewDebugPanel("")
+	}
+
+	if len(conf.Input.SendKeys) > 0 && len(conf.Input.NewlineKeys) > 0 {
+		layout.Chat.Keys = chat.NewKeyMap(conf.Input.SendKeys, conf.Input.NewlineKeys)
+	}
+
+	layout.Chat.EditorSend = conf.Input.EditorSend
+
+	if conf.Input.MaxHeight > 0 {
+		layout.Chat.MaxInputHeight = conf.Input.MaxHeight
+	}
+
+	layout.Info = layout.ChatInfo()
+
+	if storage, ok := layout.Storage.(schema.TrashStorage); ok && conf.TrashRetention > 0 {
+		count, err := storage.PurgeTrash(conf.TrashRetention)
+		if err != nil {
+			log.Printf("Error while purging trash: %s", err)
+		} else if count > 0 {
+			log.Printf("Purged %d sessions from the trash", count)
+		}
+	}
+
+	// Other processes writing to the same storage are followed live
+	if storage, ok := layout.Storage.(schema.WatchableStorage); ok {
+		layout.Changes, layout.unsubscribe = storage.Subscribe()
+	}
+
+	// Encrypted storage is unlocked first, the recent session is loaded after
+	if storage, ok := layout.Storage.(schema.LockableStorage); ok && storage.Locked() {
+		layout.Prompt = NewPassphrasePrompt(UnlockPrompt, "Passphrase to unlock your sessions")
+		return layout
 	}
 
 	if layout.Storage != nil {
@@ -52,14 +126,65 @@ func NewLayout(conf schema.Config) LayoutView {
 		if err == nil {
 			layout.Chat.Session = session
 			layout.Chat.Msgs = session.Msgs
+
+			// The stream callback is registered by Init, only pick the provider
+			if provider := layout.findProvider(session.Provider); provider != nil {
+				layout.Chat.Provider = provider
+			}
 		}
 	}
 
 	return layout
 }
 
+// ChatInfo is the info line shown while typing a prompt.
+func (layout LayoutView) ChatInfo() string {
+	if layout.Sidebar.Focused {
+		return "j/k - select | enter - open | ctrl+b - close | esc - back to the prompt"
+	}
+
+	if layout.Mode == schema.Debug {
+		return "tab - next mode | pgup/pgdown - scroll the debug panel"
+	}
+
+	if layout.Mode == schema.Action {
+		return "j/k - select | y - copy | c/1-9 - code | e - edit | d - delete | r - regenerate | v - raw | i - info | esc - back"
+	}
+
+	send := layout.Chat.Keys.Send.Help().Key
+	newline := layout.Chat.Keys.Newline.Help().Key
+	editor := layout.Chat.Keys.Editor.Help().Key
+
+	return fmt.Sprintf("%s - send | %s - newline | %s - editor | \"/\" - menu", send, newline, editor)
+}
+
+// FindInfo shows the query and the position of the current match.
+func (layout LayoutView) FindInfo(find chat.Find) string {
+	count := fmt.Sprintf("%d/%d", find.Current+1, len(find.Matches()))
+	if len(find.Matches()) == 0 {
+		count = "no matches"
+	}
+
+	if find.Editing {
+		return fmt.Sprintf("find: %s | %s | enter - done | esc - cancel", find.Query, count)
+	}
+
+	return fmt.Sprintf("find: %s | %s | n/N - next/previous | ctrl+f - edit | esc - close", find.Query, count)
+}
+
+// LoadRecentSession opens the most recently used session from storage.
+func (layout LayoutView) LoadRecentSession() LayoutView {
+	session, err := layout.Storage.LoadRecentSession()
+	if err != nil {
+		log.Printf("Error while loading recent session: %s", err)
+		return layout
+	}
+
+	return layout.OpenSession(session)
+}
+
 func (layout LayoutView) Init() tea.Cmd {
-	return tea.Batch(layout.Chat.Init())
+	return tea.Batch(layout.Chat.Init(), layout.ApplyRetention(), layout.WaitForChange(), layout.LoadSidebar())
 }
 
 func (layout LayoutView) View() string {
@@ -72,13 +197,26 @@ func (layout LayoutView) View() string {
 	header := layout.Chat.View()
 	elements = append(elements, header)
 
-	inputHeight := layout.Chat.Input.LineInfo().Height + 1
+	inputHeight := layout.Chat.Input.Height()
+	if layout.Form.Active {
+		inputHeight = layout.Form.Height()
+	}
 	baseViewportHeight := layout.WindowSize.Height - inputHeight - 7
+	width := layout.ChatWidth()
 
 	// Render Chat viewport and/or chat menu and modify the viewport height based on menu height
-	if layout.Menu.Active {
-		menuHeight := len(layout.Menu.FilteredItems)
-		layout.Chat.Viewport.Height = baseViewportHeight - menuHeight
+	if layout.Mode == schema.Debug {
+		height := baseViewportHeight
+		if layout.Menu.Active {
+			height -= layout.Menu.Height()
+		}
+
+		elements = append(elements, layout.Debug.View(width, height, layout.Style))
+		if layout.Menu.Active {
+			elements = append(elements, layout.Menu.View())
+		}
+	} else if layout.Menu.Active {
+		layout.Chat.Viewport.Height = baseViewportHeight - layout.Menu.Height()
 
 		vp := layout.Style.Chat.ContentView.Render(layout.Chat.Viewport.View())
 		elements = append(elements, vp)
@@ -87,7 +225,7 @@ func (layout LayoutView) View() string {
 	} else {
 		layout.Chat.Viewport.Height = baseViewportHeight
 		vp := lipgloss.PlaceHorizontal(
-			layout.WindowSize.Width,
+			width,
 			lipgloss.Center,
 			layout.Style.Chat.ContentView.Render(layout.Chat.Viewport.View()),
 			lipgloss.WithWhitespaceBackground(lipgloss.Color(layout.Style.WhitespaceBGcolor)),
@@ -96,24 +234,57 @@ func (layout LayoutView) View() string {
 		elements = append(elements, vp)
 	}
 
-	// Render Chat input
+	// Render Chat input, or the passphrase prompt in its place
 	input := lipgloss.PlaceHorizontal(
-		layout.WindowSize.Width,
+		width,
 		lipgloss.Center,
 		layout.Chat.Input.View(),
 		lipgloss.WithWhitespaceBackground(lipgloss.Color(layout.Style.WhitespaceBGcolor)),
 	)
 
+	if layout.Prompt.Active {
+		input = layout.Prompt.View(width, layout.Style)
+	} else if layout.Form.Active {
+		input = layout.Form.View(width, layout.Style)
+	}
+
 	elements = append(elements, input)
 
-	infoLine := layout.Style.InfoLine.Width(layout.WindowSize.Width).Render(layout.Info)
+	if layout.SidebarVisible() {
+		sidebar := layout.Sidebar.View(
+			SidebarWidth,
+			layout.SidebarHeight(),
+			layout.Chat.Session.ID,
+			layout.Chat.Loader.View(),
+			layout.Style,
+		)
+
+		column := lipgloss.JoinVertical(lipgloss.Center, elements...)
+		elements = []string{lipgloss.JoinHorizontal(lipgloss.Top, sidebar, column)}
+	}
+
+	info := layout.Info
+	if search := layout.Chat.Search; search.Active {
+		info = fmt.Sprintf("reverse search: %s", search.Query)
+		if search.Query != "" && !search.Matched(layout.Chat.History) {
+			info += " (no match)"
+		}
+
+		info += " | ctrl+r - older | esc - cancel"
+	}
+
+	if find := layout.Chat.Find; find.Open {
+		info = layout.FindInfo(find)
+	}
+
+	infoLine := layout.Style.InfoLine.Width(layout.WindowSize.Width).Render(info)
 	elements = append(elements, infoLine)
 
 	// Render the status line
 	providerType := layout.Style.StatusLine.ProviderType.Render(layout.Chat.Provider.Type().String())
 	providerName := layout.Style.StatusLine.ProviderName.Render(layout.Chat.Provider.Name())
 	tab := layout.Style.StatusLine.ModeLabel.Render("tab")
-	mode := layout.Style.StatusLine.ModeName.Render("CHAT")
+	mode := layout.Style.StatusLine.ModeName.Render(layout.Mode.String())
 
 	leftPart := lipgloss.JoinHorizontal(lipgloss.Top, providerType, providerName)
 	rightPart := lipgloss.JoinHorizontal(lipgloss.Top, tab, mode)
@@ -144,11 +315,91 @@ func (layout LayoutView) View() string {
 func (layout LayoutView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	cmds := []tea.Cmd{}
 
+	// The chat and the menu get the width left by the sidebar
+	if size, ok := msg.(tea.WindowSizeMsg); ok {
+		layout.WindowSize = size
+		msg = tea.WindowSizeMsg{Width: layout.ChatWidth(), Height: size.Height}
+	}
+
 	switch msg := msg.(type) {
-	case tea.WindowSizeMsg:
-		layout.WindowSize = msg
+	case tea.MouseMsg:
+		if layout.OverSidebar(msg.X, msg.Y) {
+			return layout.UpdateSidebarMouse(msg)
+		}
+	case SidebarMsg:
+		layout = layout.HandleSidebar(msg)
+	case chat.RunDoneMsg:
+		delete(layout.Sidebar.Running, msg.SessionID)
+		cmds = append(cmds, layout.LoadSidebar())
+
+		if msg.Err == nil {
+			layout = layout.recordProvider(msg.SessionID)
+			layout = layout.SyncMsgs(msg.SessionID)
+		}
+
+		if msg.Err == nil && layout.needsTitle(msg.SessionID) {
+			cmds = append(cmds, layout.GenerateTitle())
+		}
+	case SessionTitleMsg:
+		layout = layout.RenameSession(msg.SessionID, msg.Title)
+		cmds = append(cmds, layout.LoadSidebar())
+	case SessionPageMsg:
+		layout = layout.AppendSessionPage(msg)
+	case RetentionMsg:
+		layout = layout.HandleRetention(msg)
+		if !msg.Requested {
+			cmds = append(cmds, layout.ScheduleRetention())
+		}
+	case retentionTickMsg:
+		cmds = append(cmds, layout.ApplyRetention())
+	case DatasetMsg:
+		layout = layout.HandleDataset(msg)
+	case ContextMsg:
+		var cmd tea.Cmd
+		layout, cmd = layout.HandleContext(msg)
+		cmds = append(cmds, cmd)
+	case chat.DebugMsg:
+		layout.Debug = layout.Debug.Add(msg.Event)
+		cmds = append(cmds, layout.Chat.HandleDebug)
+	case debugTickMsg:
+		var cmd tea.Cmd
+		layout, cmd = layout.HandleDebugTick(msg)
+		cmds = append(cmds, cmd)
+	case ChangeMsg:
+		var cmd tea.Cmd
+		layout, cmd = layout.HandleChange(msg)
+		cmds = append(cmds, cmd, layout.WaitForChange(), layout.LoadSidebar())
 	case tea.KeyMsg:
+		if layout.Prompt.Active && msg.Type != tea.KeyCtrlC {
+			return layout.UpdatePrompt(msg)
+		}
+
+		if layout.Form.Active && msg.Type != tea.KeyCtrlC {
+			return layout.UpdateForm(msg)
+		}
+
+		if msg.Type == tea.KeyCtrlB {
+			return layout.ToggleSidebar()
+		}
+
+		if layout.Sidebar.Focused && layout.SidebarVisible() && msg.Type != tea.KeyCtrlC {
+			return layout.UpdateSidebar(msg)
+		}
+
+		if layout.Mode == schema.Action && msg.Type != tea.KeyCtrlC {
+			return layout.UpdateAction(msg)
+		}
+
 		switch msg.Type {
+		case tea.KeyTab:
+			if !layout.Menu.Active {
+				return layout.NextMode()
+			}
+
+			if item, ok := layout.Menu.List.SelectedItem().(CompletionItem); ok {
+				layout.Chat.Input.SetValue(item.Prompt())
+				return layout.SyncMenu(), nil
+			}
 		case tea.KeyEsc:
 			if layout.Menu.Active {
 				layout.Menu = layout.Menu.Close()
@@ -160,25 +411,32 @@ func (layout LayoutView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	}
 
-	prompt := layout.Chat.Input.Value()
-
-	layout.Menu.Active = strings.HasPrefix(prompt, "/")
-	if layout.Menu.Active {
-		layout.Info = "ctrl+j - down | ctrl+k - up"
-		layout.Menu.SearchString = strings.TrimPrefix(prompt, "/")
-	} else {
-		layout.Info = "enter - send | \"/\" - menu"
+	layout = layout.SyncMenu()
+	if !layout.Menu.Active {
+		layout.Info = layout.ChatInfo()
 	}
 
 	menuModel, cmd := layout.Menu.Update(msg)
 	layout.Menu = menuModel.(menu.ChatMenu)
 	cmds = append(cmds, cmd)
 
+	if !layout.Menu.Active {
+		layout.SessionPages = SessionPages{}
+	}
+
+	// Load the next page of sessions when the selection nears the end
+	pages := layout.SessionPages
+	if pages.Active && !pages.Loading && !pages.Done && layout.Menu.List.Index() >= len(layout.Menu.FilteredItems)-3 {
+		layout.SessionPages.Loading = true
+		cmds = append(cmds, layout.LoadSessionPage(pages.Offset))
+	}
+
 	if keyMsg, ok := msg.(tea.KeyMsg); ok && keyMsg.Type == tea.KeyEnter {
 		if layout.Menu.Active && len(layout.Menu.FilteredItems) > 0 {
 			selected, ok := layout.Menu.List.SelectedItem().(schema.CmdItem)
 			if ok && selected != nil {
-				return selected.Execute(layout)
+				layout.Menu = layout.Menu.Used(selected)
+				return layout.ExecuteCmd(selected)
 			}
 		}
 	}
@@ -187,5 +445,319 @@ func (layout LayoutView) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	layout.Chat = chatModel.(chat.ChatView)
 	cmds = append(cmds, cmd)
 
+	if layout.Chat.IsLoading {
+		layout.Sidebar.Running[layout.Chat.Session.ID] = true
+	}
+
+	// The menu follows the prompt as changed by this key
+	if _, ok := msg.(tea.KeyMsg); ok {
+		layout = layout.SyncMenu()
+	}
+
+	if layout.Mode == schema.Debug {
+		debugView, cmd := layout.Debug.Viewport.Update(msg)
+		*layout.Debug.Viewport = debugView
+		cmds = append(cmds, cmd)
+	}
+
 	return layout, tea.Batch(cmds...)
 }
+
+// SessionTitleMsg carries a generated title for a session.
+type SessionTitleMsg struct {
+	SessionID string
+	Title     string
+}
+
+// needsTitle reports whether the session still has its default title and
+// just finished its first exchange.
+func (layout LayoutView) needsTitle(sessionID string) bool {
+	if layout.AutoTitle == nil || layout.Chat.Session.ID != sessionID {
+		return false
+	}
+
+	if !strings.HasPrefix(layout.Chat.Session.Title, "Session - ") {
+		return false
+	}
+
+	userMsgs := 0
+	for _, msg := range layout.Chat.Msgs {
+		if msg.Role == schema.UserMsg {
+			userMsgs++
+		}
+	}
+
+	return userMsgs == 1
+}
+
+// GenerateTitle asks the auto title provider for a title of the current
+// session in the background.
+func (layout LayoutView) GenerateTitle() tea.Cmd {
+	titler := layout.AutoTitle
+	session := layout.Chat.Session
+	session.Msgs = layout.Chat.Msgs
+
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		title, err := titler.GenerateTitle(ctx, session)
+		if err != nil {
+			log.Printf("Error while generating session title: %v", err)
+			return nil
+		}
+
+		return SessionTitleMsg{SessionID: session.ID, Title: title}
+	}
+}
+
+// RenameSession renames the session in storage and updates the chat header
+// when it is the currently open session.
+func (layout LayoutView) RenameSession(sessionID string, title string) LayoutView {
+	if layout.Storage != nil {
+		err := metadataStorage(layout.Storage).RenameSession(sessionID, title)
+		if err != nil {
+			log.Printf("Error while renaming session: %s", err)
+		}
+	}
+
+	if layout.Chat.Session.ID == sessionID {
+		layout.Chat.Session.Title = title
+	}
+
+	return layout
+}
+
+// AddMsg appends a message to the chat transcript without sending it to the
+// provider, used for command feedback.
+func (layout LayoutView) AddMsg(role schema.MsgRole, content string) LayoutView {
+	layout.Chat.Msgs = append(layout.Chat.Msgs, schema.Msg{
+		Role:      role,
+		Content:   content,
+		Timestamp: time.Now().Unix(),
+	})
+
+	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
+	layout.Chat.Viewport.GotoBottom()
+
+	return layout
+}
+
+// SyncMsgs copies the IDs and metadata storage assigned to the messages of
+// the last run onto the displayed messages, keeping the notes in between.
+func (layout LayoutView) SyncMsgs(sessionID string) LayoutView {
+	if layout.Storage == nil || layout.Chat.Session.ID != sessionID {
+		return layout
+	}
+
+	session, err := layout.Storage.LoadSession(sessionID)
+	if err != nil {
+		log.Printf("Error while syncing messages: %s", err)
+		return layout
+	}
+
+	stored := session.Msgs
+	for i := len(layout.Chat.Msgs) - 1; i >= 0 && len(stored) > 0; i-- {
+		msg := layout.Chat.Msgs[i]
+		if msg.Role != schema.UserMsg && msg.Role != schema.AIMsg {
+			continue
+		}
+
+		last := stored[len(stored)-1]
+		stored = stored[:len(stored)-1]
+
+		if msg.ID != "" || msg.Role != last.Role {
+			break
+		}
+
+		msg.ID = last.ID
+		msg.Meta = last.Meta
+		layout.Chat.Msgs[i] = msg
+	}
+
+	return layout
+}
+
+// ExportSession exports the current session to the target path, to the
+// clipboard when the target is "clipboard", or to a file named after the
+// session title when the target is empty.
+func (layout LayoutView) ExportSession(format export.Format, target string) LayoutView {
+	session := layout.Chat.Session
+	session.Msgs = layout.Chat.Msgs
+
+	if target == "clipboard" {
+		data, err := export.Render(session, format, layout.Style)
+		if err == nil {
+			err = layout.CopyText(string(data))
+		}
+
+		if err != nil {
+			return layout.AddMsg(schema.ErrMsg, err.Error())
+		}
+
+		return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Copied session as %s to the clipboard", format))
+	}
+
+	if target == "" {
+		target = export.FileName(session, format)
+	}
+
+	err := export.ToFile(session, format, layout.Style, target)
+	if err != nil {
+		return layout.AddMsg(schema.ErrMsg, err.Error())
+	}
+
+	return layout.AddMsg(schema.InternalMsg, fmt.Sprintf("Exported session to %s", target))
+}
+
+// OpenSession makes the session the current one, renders its messages and
+// switches to the provider the session was last used with.
+func (layout LayoutView) OpenSession(session schema.ChatSession) LayoutView {
+	layout.Chat.Session = session
+	layout.Chat.Msgs = session.Msgs
+	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
+	layout.Chat.Viewport.GotoBottom()
+
+	if provider := layout.findProvider(session.Provider); provider != nil {
+		layout = layout.SwitchProvider(provider)
+	}
+
+	return layout
+}
+
+// SwitchProvider makes the provider the active one and routes its stream
+// into the chat.
+func (layout LayoutView) SwitchProvider(provider schema.ChatProvider) LayoutView {
+	layout.Chat.Provider = provider
+	layout.Chat.Connect(provider)
+
+	return layout
+}
+
+func (layout LayoutView) findProvider(name string) schema.ChatProvider {
+	if name == "" {
+		return nil
+	}
+
+	for _, provider := range layout.Providers {
+		if provider.Name() == name {
+			return provider
+		}
+	}
+
+	return nil
+}
+
+// recordProvider stores the active provider on the session when it differs
+// from the one the session was last used with.
+func (layout LayoutView) recordProvider(sessionID string) LayoutView {
+	session := layout.Chat.Session
+	if session.ID != sessionID {
+		return layout
+	}
+
+	name := layout.Chat.Provider.Name()
+	model := ""
+	if provider, ok := layout.Chat.Provider.(schema.ModelProvider); ok {
+		model = provider.Model()
+	}
+
+	if session.Provider == name && session.Model == model {
+		return layout
+	}
+
+	if layout.Storage != nil {
+		err := metadataStorage(layout.Storage).SetSessionProvider(sessionID, name, model)
+		if err != nil {
+			log.Printf("Error while recording session provider: %s", err)
+		}
+	}
+
+	layout.Chat.Session.Provider = name
+	layout.Chat.Session.Model = model
+
+	return layout
+}
+
+// DeleteSession moves the session to the trash and, when it is the current
+// one, switches to the most recent remaining session.
+func (layout LayoutView) DeleteSession(sessionID string) LayoutView {
+	if layout.Storage == nil {
+		if layout.Chat.Session.ID == sessionID {
+			layout = layout.OpenSession(newSession())
+		}
+
+		return layout
+	}
+
+	err := layout.Storage.DeleteSession(sessionID)
+	if err != nil {
+		log.Printf("Error while deleting sessions: %s", err)
+		return layout.AddMsg(schema.ErrMsg, err.Error())
+	}
+
+	if layout.Chat.Session.ID == sessionID {
+		session, err := layout.Storage.LoadRecentSession()
+		if err != nil {
+			log.Printf("Error while loading recent session: %s", err)
+			session = newSession()
+		}
+
+		layout = layout.OpenSession(session)
+	}
+
+	if _, ok := layout.Storage.(schema.TrashStorage); !ok {
+		return layout.AddMsg(schema.InternalMsg, "Session deleted")
+	}
+
+	return layout.AddMsg(schema.InternalMsg, "Session moved to the trash, use /trash to restore it")
+}
+
+// newSession returns a new, unsaved session with a default title.
+func newSession() schema.ChatSession {
+	sessionID := randstr.String(8)
+
+	return schema.ChatSession{
+		ID:        sessionID,
+		Title:     fmt.Sprintf("Session - %s", sessionID),
+		Msgs:      []schema.Msg{},
+		CreatedAt: time.Now().Unix(),
+	}
+}
+
+// LoadSessionPage loads a page of session summaries in the background.
+func (layout LayoutView) LoadSessionPage(offset int) tea.Cmd {
+	storage := summaryStorage(layout.Storage)
+
+	return func() tea.Msg {
+		sessions, err := storage.ListSessionSummaries(offset, SessionPageSize)
+		return SessionPageMsg{Offset: offset, Sessions: sessions, Err: err}
+	}
+}
+
+// AppendSessionPage adds a loaded page to the sessions submenu, if it is
+// still open.
+func (layout LayoutView) AppendSessionPage(msg SessionPageMsg) LayoutView {
+	if !layout.SessionPages.Active || msg.Offset != layout.SessionPages.Offset {
+		return layout
+	}
+
+	layout.SessionPages.Loading = false
+
+	if msg.Err != nil {
+		log.Printf("Error while listing sessions: %s", msg.Err)
+		layout.SessionPages.Done = true
+		return layout
+	}
+
+	items := append([]list.Item{}, layout.Menu.CurrentItems...)
+	for _, session := range msg.Sessions {
+		items = append(items, SessionCmd{session: session})
+	}
+
+	layout.SessionPages.Offset += len(msg.Sessions)
+	layout.SessionPages.Done = len(msg.Sessions) < SessionPageSize
+	layout.Menu = layout.Menu.PushMenu(items)
+
+	return layout
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+
+	"github.com/struki84/clipt/storage"
+	"github.com/struki84/clipt/tui/schema"
+	"github.com/struki84/clipt/tui/style"
+)
+
+type testProvider struct {
+	name string
+}
+
+func (provider testProvider) Name() string              { return provider.name }
+func (provider testProvider) Type() schema.ProviderType { return schema.LLM }
+func (provider testProvider) Description() string       { return "" }
+func (provider testProvider) Run(ctx context.Context, input string, session schema.ChatSession) error {
+	return nil
+}
+func (provider testProvider) Stream(ctx context.Context, callback func(ctx context.Context, msg schema.Msg) error) {
+}
+
+// newTestLayout returns a layout on a temporary SQLite storage, with the
+// commands added to the default ones.
+func newTestLayout(t *testing.T, cmds ...list.Item) (LayoutView, *storage.SQLite) {
+	tempDir, err := os.MkdirTemp("", "tui_test")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(tempDir) })
+
+	sqliteDB := storage.NewSQLite(filepath.Join(tempDir, "test.db"))
+	if sqliteDB == nil {
+		t.Fatal("Failed to initialize SQLite")
+	}
+
+	layout := NewLayout(schema.Config{
+		Cmds:      slices.Concat(DefaultCmds, cmds),
+		Providers: []schema.ChatProvider{testProvider{name: "first"}, testProvider{name: "second"}},
+		Style:     style.Default(style.Dark),
+		Storage:   sqliteDB,
+	})
+	t.Cleanup(layout.Close)
+
+	return layout, sqliteDB
+}
+
+// openTestSession saves the messages as a session and opens it with the
+// notes inserted at their index, like notes added by commands.
+func openTestSession(t *testing.T, layout LayoutView, msgs []schema.Msg, notes map[int]string) LayoutView {
+	session, err := layout.Storage.NewSession()
+	if err != nil {
+		t.Fatalf("Failed to create session: %v", err)
+	}
+
+	session.Title = "Test session"
+	session.Msgs = msgs
+
+	_, err = layout.Storage.SaveSession(session)
+	if err != nil {
+		t.Fatalf("Failed to save session: %v", err)
+	}
+
+	session, err = layout.Storage.LoadSession(session.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+
+	shown := []schema.Msg{}
+	for i, msg := range session.Msgs {
+		if note, ok := notes[i]; ok {
+			shown = append(shown, schema.Msg{Role: schema.InternalMsg, Content: note})
+		}
+
+		shown = append(shown, msg)
+	}
+
+	layout = layout.OpenSession(session)
+	layout.Chat.Msgs = shown
+
+	return layout
+}
+
+func testMsgs() []schema.Msg {
+	return []schema.Msg{
+		{Role: schema.UserMsg, Content: "first question"},
+		{Role: schema.AIMsg, Content: "first answer"},
+		{Role: schema.UserMsg, Content: "second question"},
+		{Role: schema.AIMsg, Content: "second answer"},
+	}
+}
+
+func TestLayoutClose(t *testing.T) {
+	layout, _ := newTestLayout(t)
+	if layout.Changes == nil {
+		t.Fatal("Expected the layout to subscribe to storage changes")
+	}
+
+	layout.Close()
+
+	// Events published before closing are still buffered, the channel
+	// ends once they are drained.
+	for range layout.Changes {
+	}
+
+	if msg := layout.WaitForChange()(); msg != nil {
+		t.Fatalf("Expected no change after closing, got %v", msg)
+	}
+}
@@ -3,14 +3,20 @@ package menu
 import (
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/struki84/clipt/tui/schema"
 )
 
 type MenuDelegate struct {
 	Style schema.LayoutStyle
+
+	highlights *[][]int
 }
 
 func NewMenuDelegate(style schema.LayoutStyle) MenuDelegate {
@@ -36,7 +42,31 @@ func (delegate MenuDelegate) Render(w io.Writer, m list.Model, index int, item l
 	}
 
 	title := titleStyle.Render(i.Title())
+	if delegate.highlights != nil && index < len(*delegate.highlights) {
+		title = delegate.highlight(title, i.Title(), (*delegate.highlights)[index], titleStyle)
+	}
+
 	desc := delegate.Style.Menu.Description.Render(i.Description())
 
 	fmt.Fprint(w, title+desc)
 }
+
+// highlight styles the matched bytes of the title in its rendering.
+func (delegate MenuDelegate) highlight(rendered string, title string, matched []int, style lipgloss.Style) string {
+	offset := strings.Index(ansi.Strip(rendered), title)
+	if len(matched) == 0 || offset < 0 {
+		return rendered
+	}
+
+	offset = ansi.StringWidth(ansi.Strip(rendered)[:offset])
+	match := delegate.Style.Menu.Match.Background(style.GetBackground())
+
+	ranges := []lipgloss.Range{}
+	for _, index := range matched {
+		_, size := utf8.DecodeRuneInString(title[index:])
+		start := offset + ansi.StringWidth(title[:index])
+		ranges = append(ranges, lipgloss.NewRange(start, start+ansi.StringWidth(title[index:index+size]), match))
+	}
+
+	return lipgloss.StyleRanges(rendered, ranges...)
+}
@@ -1,15 +1,27 @@
 package menu
 
 import (
+	"math"
+	"slices"
+	"sort"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/sahilm/fuzzy"
 	"github.com/struki84/clipt/tui/schema"
 )
 
+const (
+	// recentLimit is the number of recently used commands boosted in the menu
+	recentLimit = 5
+
+	// recentBoost is added to the score of a recent command per rank
+	recentBoost = 5
+)
+
 type ChatMenu struct {
 	WindowSize tea.WindowSizeMsg
 	Style      schema.LayoutStyle
@@ -20,11 +32,26 @@ type ChatMenu struct {
 	FilteredItems []list.Item
 	SearchString  string
 
-	Active bool
+	Active  bool
+	Submenu bool
+
+	// Suggesting is set while values for an argument are listed in place
+	// of the commands.
+	Suggesting bool
+
+	// recent are the titles of the last used commands, most recent first,
+	// and highlights the matched bytes of the titles of FilteredItems.
+	recent     *[]string
+	highlights *[][]int
 }
 
 func New(cmds []list.Item, style schema.LayoutStyle) ChatMenu {
-	list := list.New(cmds, NewMenuDelegate(style), 0, 0)
+	highlights := &[][]int{}
+
+	delegate := NewMenuDelegate(style)
+	delegate.highlights = highlights
+
+	list := list.New(cmds, delegate, 0, 0)
 	list.SetShowTitle(false)
 	list.SetShowHelp(false)
 	list.SetShowPagination(false)
@@ -40,6 +67,8 @@ func New(cmds []list.Item, style schema.LayoutStyle) ChatMenu {
 		CurrentItems:  cmds,
 		FilteredItems: cmds,
 		Style:         style,
+		recent:        &[]string{},
+		highlights:    highlights,
 	}
 }
 
@@ -47,7 +76,8 @@ func (menu ChatMenu) Init() tea.Cmd {
 	return nil
 }
 
-func (menu ChatMenu) View() string {
+// Height returns the number of rows the menu takes up when rendered.
+func (menu ChatMenu) Height() int {
 	menuHeight := len(menu.FilteredItems)
 	if menuHeight == 0 {
 		menuHeight = 1
@@ -57,6 +87,12 @@ func (menu ChatMenu) View() string {
 		menuHeight = 10
 	}
 
+	return menuHeight
+}
+
+func (menu ChatMenu) View() string {
+	menuHeight := menu.Height()
+
 	menu.List.SetSize(menu.WindowSize.Width-4, menuHeight)
 
 	list := menu.Style.Menu.ContentView.
@@ -80,17 +116,9 @@ func (menu ChatMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		menu.WindowSize = msg
 	}
 
-	if menu.Active {
-		menu.FilteredItems = []list.Item{}
-
-		for _, item := range menu.CurrentItems {
-			if strings.Contains(strings.ToLower(item.FilterValue()), strings.ToLower(menu.SearchString)) {
-				menu.FilteredItems = append(menu.FilteredItems, item)
-			}
-		}
-
-		menu.List.SetItems(menu.FilteredItems)
-	} else {
+	if menu.Active && !menu.Suggesting {
+		menu = menu.Filter()
+	} else if !menu.Active {
 		menu = menu.Reset()
 	}
 
@@ -101,18 +129,168 @@ func (menu ChatMenu) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return menu, tea.Batch(cmds...)
 }
 
+// Filter keeps the current items fuzzy matching the search, ranked by the
+// score of the match and, in the commands menu, how recently they were used.
+func (menu ChatMenu) Filter() ChatMenu {
+	items, highlights := menu.rank(menu.CurrentItems, menu.SearchString)
+
+	menu.Suggesting = false
+	menu.FilteredItems = items
+	menu.setHighlights(highlights)
+	menu.List.SetItems(menu.FilteredItems)
+
+	return menu
+}
+
+// Suggest lists the items matching the search in place of the commands,
+// it reports false and leaves the menu as it is when none match.
+func (menu ChatMenu) Suggest(suggestions []list.Item, search string) (ChatMenu, bool) {
+	items, highlights := menu.rank(suggestions, search)
+	if len(items) == 0 {
+		return menu, false
+	}
+
+	menu.Suggesting = true
+	menu.FilteredItems = items
+	menu.setHighlights(highlights)
+	menu.List.SetItems(menu.FilteredItems)
+
+	return menu, true
+}
+
+// Command returns the command of the current menu with the title.
+func (menu ChatMenu) Command(title string) schema.CmdItem {
+	for _, item := range menu.CurrentItems {
+		if cmd, ok := item.(schema.CmdItem); ok && cmd.Title() == title {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
+func (menu ChatMenu) rank(items []list.Item, search string) ([]list.Item, [][]int) {
+	type result struct {
+		item      list.Item
+		score     int
+		highlight []int
+	}
+
+	results := []result{}
+	typed := strings.ToLower(search)
+	search = strings.TrimSpace(search)
+
+	for _, item := range items {
+		score := menu.boost(item)
+
+		// Keep commands matched when arguments are typed after their name
+		name := strings.ToLower(strings.TrimPrefix(item.FilterValue(), "/")) + " "
+		if strings.HasPrefix(typed, name) {
+			results = append(results, result{item: item, score: math.MaxInt32})
+			continue
+		}
+
+		if search == "" {
+			results = append(results, result{item: item, score: score})
+			continue
+		}
+
+		matches := fuzzy.Find(search, []string{item.FilterValue()})
+		if len(matches) == 0 {
+			continue
+		}
+
+		highlight := []int{}
+		if cmd, ok := item.(schema.CmdItem); ok {
+			if titleMatches := fuzzy.Find(search, []string{cmd.Title()}); len(titleMatches) > 0 {
+				highlight = titleMatches[0].MatchedIndexes
+			}
+		}
+
+		results = append(results, result{item: item, score: matches[0].Score + score, highlight: highlight})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].score > results[j].score
+	})
+
+	ranked := []list.Item{}
+	highlights := [][]int{}
+
+	for _, result := range results {
+		ranked = append(ranked, result.item)
+		highlights = append(highlights, result.highlight)
+	}
+
+	return ranked, highlights
+}
+
+// Used records the command as the most recently used one, only the
+// commands of the top menu are recorded.
+func (menu ChatMenu) Used(item list.Item) ChatMenu {
+	cmd, ok := item.(schema.CmdItem)
+	if !ok || menu.recent == nil {
+		return menu
+	}
+
+	isDefault := slices.ContainsFunc(menu.DefaultItems, func(item list.Item) bool {
+		defaultCmd, ok := item.(schema.CmdItem)
+		return ok && defaultCmd.Title() == cmd.Title()
+	})
+
+	if !isDefault {
+		return menu
+	}
+
+	recent := slices.DeleteFunc(*menu.recent, func(title string) bool {
+		return title == cmd.Title()
+	})
+
+	recent = append([]string{cmd.Title()}, recent...)
+	*menu.recent = recent[:min(len(recent), recentLimit)]
+
+	return menu
+}
+
+// boost is the score added to recently used commands.
+func (menu ChatMenu) boost(item list.Item) int {
+	cmd, ok := item.(schema.CmdItem)
+	if !ok || menu.Submenu || menu.recent == nil {
+		return 0
+	}
+
+	rank := slices.Index(*menu.recent, cmd.Title())
+	if rank < 0 {
+		return 0
+	}
+
+	return (recentLimit - rank) * recentBoost
+}
+
+func (menu ChatMenu) setHighlights(highlights [][]int) {
+	if menu.highlights != nil {
+		*menu.highlights = highlights
+	}
+}
+
 func (menu ChatMenu) PushMenu(submenu []list.Item) ChatMenu {
+	menu.Submenu = true
+	menu.Suggesting = false
 	menu.FilteredItems = submenu
 	menu.CurrentItems = submenu
+	menu.setHighlights(nil)
 	menu.List.SetItems(submenu)
 
 	return menu
 }
 
 func (menu ChatMenu) Reset() ChatMenu {
+	menu.Submenu = false
+	menu.Suggesting = false
 	menu.SearchString = ""
 	menu.CurrentItems = menu.DefaultItems
 	menu.FilteredItems = menu.DefaultItems
+	menu.setHighlights(nil)
 	menu.List.SetItems(menu.DefaultItems)
 
 	return menu
@@ -0,0 +1,88 @@
+package menu
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/style"
+)
+
+type testCmd struct {
+	title string
+}
+
+func (cmd testCmd) Title() string       { return cmd.title }
+func (cmd testCmd) Description() string { return "" }
+func (cmd testCmd) FilterValue() string { return cmd.title }
+func (cmd testCmd) Execute(model tea.Model) (tea.Model, tea.Cmd) {
+	return model, nil
+}
+
+func testItems() []list.Item {
+	items := []list.Item{}
+	for _, title := range []string{"/models", "/agents", "/sessions", "/new", "/rename", "/retention", "/export", "/exit", "/rekey"} {
+		items = append(items, testCmd{title: title})
+	}
+
+	return items
+}
+
+func titles(items []list.Item) []string {
+	result := []string{}
+	for _, item := range items {
+		result = append(result, item.(testCmd).title)
+	}
+
+	return result
+}
+
+func TestRank(t *testing.T) {
+	tests := []struct {
+		search string
+		used   string
+		ranked []string
+	}{
+		// Without a query the menu keeps its order
+		{"", "", []string{"/models", "/agents", "/sessions", "/new", "/rename", "/retention", "/export", "/exit", "/rekey"}},
+		// Shorter names rank first for the same match
+		{"re", "", []string{"/rekey", "/rename", "/retention"}},
+		{"ex", "", []string{"/exit", "/export"}},
+		// Letters spread over the name still match
+		{"rnm", "", []string{"/rename"}},
+		{"xt", "", []string{"/exit", "/export"}},
+		// Letters out of order don't
+		{"eman", "", []string{}},
+		// Commands stay matched while their arguments are typed
+		{"rename my notes", "", []string{"/rename"}},
+		// Recently used commands rank first
+		{"", "/rename", []string{"/rename", "/models", "/agents", "/sessions", "/new", "/retention", "/export", "/exit", "/rekey"}},
+		{"re", "/retention", []string{"/retention", "/rekey", "/rename"}},
+	}
+
+	for _, test := range tests {
+		menu := New(testItems(), style.Default(style.Dark))
+		if test.used != "" {
+			menu = menu.Used(testCmd{title: test.used})
+		}
+
+		ranked, highlights := menu.rank(menu.DefaultItems, test.search)
+		if !slices.Equal(titles(ranked), test.ranked) {
+			t.Errorf("Expected %q to rank %v, got %v", test.search, test.ranked, titles(ranked))
+		}
+
+		if len(highlights) != len(ranked) {
+			t.Errorf("Expected a highlight for every item, got %d for %d", len(highlights), len(ranked))
+		}
+	}
+}
+
+func TestRankHighlights(t *testing.T) {
+	menu := New(testItems(), style.Default(style.Dark))
+
+	ranked, highlights := menu.rank(menu.DefaultItems, "rnm")
+	if len(ranked) != 1 || !slices.Equal(highlights[0], []int{1, 3, 5}) {
+		t.Errorf("Expected r, n and m of /rename highlighted, got %v", highlights)
+	}
+}
@@ -0,0 +1,173 @@
+package tui
+
+import (
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+type PromptPurpose int
+
+const (
+	UnlockPrompt PromptPurpose = iota
+	RekeyPrompt
+	RekeyConfirmPrompt
+	TextPrompt
+)
+
+// Prompt is an input shown in place of the chat input, masked while the
+// storage is unlocked or re-keyed and plain when a command asks for text.
+type Prompt struct {
+	Active  bool
+	Purpose PromptPurpose
+	Label   string
+	Err     string
+	Input   *textinput.Model
+
+	passphrase string
+	done       func(LayoutView, string) (LayoutView, tea.Cmd)
+}
+
+// NewPrompt returns an active prompt, a masked one hides what is typed.
+func NewPrompt(purpose PromptPurpose, label string, masked bool) Prompt {
+	input := textinput.New()
+	input.Prompt = ""
+	input.Focus()
+
+	if masked {
+		input.EchoMode = textinput.EchoPassword
+		input.EchoCharacter = '•'
+	}
+
+	return Prompt{
+		Active:  true,
+		Purpose: purpose,
+		Label:   label,
+		Input:   &input,
+	}
+}
+
+// NewPassphrasePrompt asks for a passphrase of the storage.
+func NewPassphrasePrompt(purpose PromptPurpose, label string) Prompt {
+	return NewPrompt(purpose, label, true)
+}
+
+// NewTextPrompt asks for a line of text and calls done with it.
+func NewTextPrompt(label string, done func(LayoutView, string) (LayoutView, tea.Cmd)) Prompt {
+	prompt := NewPrompt(TextPrompt, label, false)
+	prompt.done = done
+
+	return prompt
+}
+
+func (prompt Prompt) View(width int, style schema.LayoutStyle) string {
+	label := prompt.Label
+	if prompt.Err != "" {
+		label += " - " + prompt.Err
+	}
+
+	prompt.Input.Width = width - 8
+	content := lipgloss.JoinVertical(lipgloss.Left, label, prompt.Input.View())
+
+	return lipgloss.PlaceHorizontal(
+		width,
+		lipgloss.Center,
+		style.Chat.Input.Width(width-4).Render(content),
+		lipgloss.WithWhitespaceBackground(lipgloss.Color(style.WhitespaceBGcolor)),
+	)
+}
+
+// UpdatePrompt handles key presses while the passphrase prompt is open.
+func (layout LayoutView) UpdatePrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if layout.Prompt.Purpose == TextPrompt {
+		return layout.UpdateTextPrompt(msg)
+	}
+
+	storage, ok := layout.Storage.(schema.LockableStorage)
+	if !ok {
+		layout.Prompt = Prompt{}
+		return layout, nil
+	}
+
+	switch msg.Type {
+	case tea.KeyEsc:
+		// The storage can't be used until it is unlocked
+		if layout.Prompt.Purpose != UnlockPrompt {
+			layout.Prompt = Prompt{}
+		}
+
+		return layout, nil
+
+	case tea.KeyEnter:
+		passphrase := layout.Prompt.Input.Value()
+		if passphrase == "" {
+			return layout, nil
+		}
+
+		switch layout.Prompt.Purpose {
+		case UnlockPrompt:
+			err := storage.Unlock(passphrase)
+			if err != nil {
+				layout.Prompt.Err = err.Error()
+				layout.Prompt.Input.Reset()
+				return layout, nil
+			}
+
+			layout.Prompt = Prompt{}
+			layout = layout.LoadRecentSession()
+
+		case RekeyPrompt:
+			prompt := NewPassphrasePrompt(RekeyConfirmPrompt, "Repeat the new passphrase")
+			prompt.passphrase = passphrase
+			layout.Prompt = prompt
+
+		case RekeyConfirmPrompt:
+			if passphrase != layout.Prompt.passphrase {
+				layout.Prompt = NewPassphrasePrompt(RekeyPrompt, "New passphrase")
+				layout.Prompt.Err = "passphrases don't match"
+				return layout, nil
+			}
+
+			layout.Prompt = Prompt{}
+
+			err := storage.Rekey(passphrase)
+			if err != nil {
+				return layout.AddMsg(schema.ErrMsg, err.Error()), nil
+			}
+
+			layout = layout.AddMsg(schema.InternalMsg, "Sessions re-encrypted with the new passphrase")
+		}
+
+		return layout, nil
+	}
+
+	input, cmd := layout.Prompt.Input.Update(msg)
+	layout.Prompt.Input = &input
+
+	return layout, cmd
+}
+
+// UpdateTextPrompt handles key presses while a command asks for text.
+func (layout LayoutView) UpdateTextPrompt(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		layout.Prompt = Prompt{}
+		return layout, nil
+	case tea.KeyEnter:
+		done := layout.Prompt.done
+		value := layout.Prompt.Input.Value()
+		layout.Prompt = Prompt{}
+
+		if done == nil {
+			return layout, nil
+		}
+
+		return done(layout, value)
+	}
+
+	input, cmd := layout.Prompt.Input.Update(msg)
+	layout.Prompt.Input = &input
+
+	return layout, cmd
+}
@@ -0,0 +1,111 @@
+package tui
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// RetentionMsg reports the outcome of enforcing the retention policy,
+// Requested when it was run with /retention.
+type RetentionMsg struct {
+	Report    schema.RetentionReport
+	Err       error
+	Requested bool
+}
+
+type retentionTickMsg struct{}
+
+// ApplyRetention enforces the retention policy in the background, if one is
+// configured and supported by the storage.
+func (layout LayoutView) ApplyRetention() tea.Cmd {
+	storage, ok := layout.Storage.(schema.RetentionStorage)
+	if !ok || !layout.Retention.Enabled() {
+		return nil
+	}
+
+	policy := layout.Retention
+
+	return func() tea.Msg {
+		report, err := storage.ApplyRetention(policy, false)
+		return RetentionMsg{Report: report, Err: err}
+	}
+}
+
+// RequestRetention previews or enforces the retention policy in the
+// background for /retention, the report is added to the transcript.
+func (layout LayoutView) RequestRetention(storage schema.RetentionStorage, dryRun bool) tea.Cmd {
+	policy := layout.Retention
+
+	return func() tea.Msg {
+		report, err := storage.ApplyRetention(policy, dryRun)
+		return RetentionMsg{Report: report, Err: err, Requested: true}
+	}
+}
+
+// ScheduleRetention schedules the next enforcement of the retention policy.
+func (layout LayoutView) ScheduleRetention() tea.Cmd {
+	interval := layout.Retention.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	return tea.Tick(interval, func(time.Time) tea.Msg {
+		return retentionTickMsg{}
+	})
+}
+
+func (layout LayoutView) HandleRetention(msg RetentionMsg) LayoutView {
+	if msg.Err != nil {
+		log.Printf("Error while applying retention policy: %s", msg.Err)
+
+		if msg.Requested {
+			layout = layout.AddMsg(schema.ErrMsg, msg.Err.Error())
+		}
+
+		return layout
+	}
+
+	if msg.Requested {
+		layout = layout.AddMsg(schema.InternalMsg, RetentionReport(msg.Report))
+	}
+
+	if msg.Report.DryRun || len(msg.Report.Removed) == 0 {
+		return layout
+	}
+
+	log.Printf("Retention policy removed %d sessions (%d bytes)", len(msg.Report.Removed), msg.Report.Bytes)
+
+	// Move away from the open session if it was removed
+	for _, session := range msg.Report.Removed {
+		if session.ID == layout.Chat.Session.ID {
+			return layout.LoadRecentSession()
+		}
+	}
+
+	return layout
+}
+
+// RetentionReport formats a retention report for the transcript.
+func RetentionReport(report schema.RetentionReport) string {
+	verb := "Removed"
+	if report.DryRun {
+		verb = "Would remove"
+	}
+
+	if len(report.Removed) == 0 {
+		return "Retention policy: nothing to remove"
+	}
+
+	lines := []string{fmt.Sprintf("Retention policy: %s %d sessions (%d KB)", strings.ToLower(verb), len(report.Removed), report.Bytes/1024)}
+	for _, session := range report.Removed {
+		date := time.Unix(session.UpdatedAt, 0).Format("2 Jan 2006")
+		lines = append(lines, fmt.Sprintf("- %s (last used %s)", session.Title, date))
+	}
+
+	return strings.Join(lines, "\n")
+}
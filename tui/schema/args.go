@@ -0,0 +1,146 @@
+package schema
+
+import "strings"
+
+// Args are the arguments typed after a command name. Words are separated
+// by whitespace, a word in double or single quotes may contain spaces and a
+// backslash escapes the next character outside single quotes.
+type Args struct {
+	Name  string
+	Raw   string
+	Words []string
+
+	// Partial is the word being typed, empty after a trailing space.
+	Partial string
+}
+
+// ParseArgs splits a prompt like `/tag "long name" short` into the command
+// name and its arguments.
+func ParseArgs(prompt string) Args {
+	prompt = strings.TrimLeft(prompt, " \t")
+	name, rest, _ := strings.Cut(prompt, " ")
+
+	args := Args{Name: name, Raw: strings.TrimSpace(rest), Words: []string{}}
+
+	word := strings.Builder{}
+	quote := rune(0)
+	inWord := false
+	escaped := false
+
+	for _, char := range rest {
+		switch {
+		case escaped:
+			word.WriteRune(char)
+			escaped = false
+		case char == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(char)
+		case char == '"' || char == '\'':
+			quote = char
+			inWord = true
+		case char == ' ' || char == '\t' || char == '\n':
+			if inWord {
+				args.Words = append(args.Words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(char)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		args.Words = append(args.Words, word.String())
+		args.Partial = word.String()
+	}
+
+	return args
+}
+
+// Len is the number of words.
+func (args Args) Len() int {
+	return len(args.Words)
+}
+
+// Get returns the word at the index, empty when it's missing.
+func (args Args) Get(index int) string {
+	if index < 0 || index >= len(args.Words) {
+		return ""
+	}
+
+	return args.Words[index]
+}
+
+// Current is the index of the word being typed.
+func (args Args) Current() int {
+	if args.Partial != "" {
+		return len(args.Words) - 1
+	}
+
+	return len(args.Words)
+}
+
+// Text is the raw text of the arguments, for commands taking free text like
+// a title. Quotes around the whole text, as added by completion, are removed.
+func (args Args) Text() string {
+	raw := args.Raw
+	quoted := len(raw) > 1 && strings.ContainsAny(raw[:1], `"'`) && raw[len(raw)-1] == raw[0]
+
+	if quoted && len(args.Words) == 1 {
+		return args.Words[0]
+	}
+
+	return raw
+}
+
+// From joins the words from the index on.
+func (args Args) From(index int) string {
+	if index >= len(args.Words) {
+		return ""
+	}
+
+	return strings.Join(args.Words[index:], " ")
+}
+
+// Complete returns the prompt with the word being typed replaced by the
+// value, quoted when it contains spaces or quotes.
+func (args Args) Complete(value string) string {
+	words := args.Words
+	if args.Partial != "" {
+		words = words[:len(words)-1]
+	}
+
+	line := []string{args.Name}
+	for _, word := range append(append([]string{}, words...), value) {
+		line = append(line, quoteWord(word))
+	}
+
+	return strings.Join(line, " ")
+}
+
+// quoteWord quotes a word that wouldn't be parsed back as it is.
+func quoteWord(word string) string {
+	if word != "" && !strings.ContainsAny(word, " \t\n\"'\\") {
+		return word
+	}
+
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(word) + `"`
+}
+
+// RequiredArgs counts the <required> arguments of a usage like
+// "/export <format> [path]".
+func RequiredArgs(usage string) int {
+	count := 0
+	for _, word := range strings.Fields(usage) {
+		if strings.HasPrefix(word, "<") {
+			count++
+		}
+	}
+
+	return count
+}
@@ -0,0 +1,119 @@
+package schema
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		prompt  string
+		name    string
+		words   []string
+		partial string
+	}{
+		{"/tag", "/tag", []string{}, ""},
+		{"/tag ", "/tag", []string{}, ""},
+		{"/tag one  two", "/tag", []string{"one", "two"}, "two"},
+		{"/tag one two ", "/tag", []string{"one", "two"}, ""},
+		{`/tag "side project" short`, "/tag", []string{"side project", "short"}, "short"},
+		{`/tag 'side project'`, "/tag", []string{"side project"}, "side project"},
+		{`/tag "it's" 'say "hi"'`, "/tag", []string{"it's", `say "hi"`}, `say "hi"`},
+		{`/tag ""`, "/tag", []string{""}, ""},
+		// Escapes
+		{`/tag side\ project`, "/tag", []string{"side project"}, "side project"},
+		{`/tag "a \"b\" c" d\\e`, "/tag", []string{`a "b" c`, `d\e`}, `d\e`},
+		{`/tag 'a\b'`, "/tag", []string{`a\b`}, `a\b`},
+		// Unterminated quotes run to the end of the prompt
+		{`/rename "Bob's notes`, "/rename", []string{"Bob's notes"}, "Bob's notes"},
+		{`/rename Bob's notes`, "/rename", []string{"Bobs notes"}, "Bobs notes"},
+	}
+
+	for _, test := range tests {
+		args := ParseArgs(test.prompt)
+		if args.Name != test.name || !slices.Equal(args.Words, test.words) || args.Partial != test.partial {
+			t.Errorf("Expected %q to parse as %s %q partial %q, got %s %q partial %q",
+				test.prompt, test.name, test.words, test.partial, args.Name, args.Words, args.Partial)
+		}
+	}
+}
+
+func TestArgs(t *testing.T) {
+	args := ParseArgs(`/export json "my notes.json" extra`)
+
+	if args.Len() != 3 || args.Get(0) != "json" || args.Get(3) != "" || args.Get(-1) != "" {
+		t.Errorf("Unexpected words %q", args.Words)
+	}
+
+	if args.From(1) != "my notes.json extra" || args.From(3) != "" {
+		t.Errorf("Unexpected From: %q, %q", args.From(1), args.From(3))
+	}
+
+	if args.Current() != 2 || ParseArgs("/export json ").Current() != 1 {
+		t.Errorf("Unexpected current word %d", args.Current())
+	}
+
+	if args.Raw != `json "my notes.json" extra` {
+		t.Errorf("Unexpected raw text %q", args.Raw)
+	}
+}
+
+func TestArgsText(t *testing.T) {
+	tests := map[string]string{
+		`/rename Bob's notes`:         "Bob's notes",
+		`/rename   Bob's notes  `:     "Bob's notes",
+		`/rename "Bob's notes"`:       "Bob's notes",
+		`/rename "say \"hi\""`:        `say "hi"`,
+		`/rename 'one' and 'two'`:     "'one' and 'two'",
+		`/rename "Notes" on "things"`: `"Notes" on "things"`,
+		`/rename`:                     "",
+	}
+
+	for prompt, text := range tests {
+		if got := ParseArgs(prompt).Text(); got != text {
+			t.Errorf("Expected %q to have the text %q, got %q", prompt, text, got)
+		}
+	}
+}
+
+func TestArgsComplete(t *testing.T) {
+	tests := []struct {
+		prompt string
+		value  string
+		line   string
+	}{
+		{"/export j", "json", "/export json"},
+		{"/export json ", "clipboard", "/export json clipboard"},
+		{"/rename ", "Bob's notes", `/rename "Bob's notes"`},
+		{"/rename ", `say "hi"`, `/rename "say \"hi\""`},
+		{`/tag "side project" o`, "other", `/tag "side project" other`},
+	}
+
+	for _, test := range tests {
+		line := ParseArgs(test.prompt).Complete(test.value)
+		if line != test.line {
+			t.Errorf("Expected %q completed with %q to be %q, got %q", test.prompt, test.value, test.line, line)
+		}
+
+		// Completed values parse back as they were
+		args := ParseArgs(line)
+		if args.Get(args.Len()-1) != test.value {
+			t.Errorf("Expected %q to parse back to %q, got %q", line, test.value, args.Words)
+		}
+	}
+}
+
+func TestRequiredArgs(t *testing.T) {
+	tests := map[string]int{
+		"/find [text]":       0,
+		"/rename <title>":    1,
+		"/move <from> <to>":  2,
+		"/tag <tag>... [id]": 1,
+	}
+
+	for usage, count := range tests {
+		if got := RequiredArgs(usage); got != count {
+			t.Errorf("Expected %d required arguments in %q, got %d", count, usage, got)
+		}
+	}
+}
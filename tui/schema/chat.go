@@ -3,6 +3,7 @@ package schema
 import (
 	"context"
 	"fmt"
+	"time"
 )
 
 // Chat schema
@@ -51,12 +52,39 @@ func EnumRole(s string) MsgRole {
 }
 
 type Msg struct {
+	ID        string
 	Stream    bool
 	Role      MsgRole
 	Content   string
 	Timestamp int64
+	Meta      MsgMeta
 }
 
+// MsgMeta holds metadata recorded with a message, such as the provider that
+// produced it. Keys other than the ones below are free to use.
+type MsgMeta map[string]string
+
+// Well known message metadata keys
+const (
+	MetaProvider         = "provider"
+	MetaModel            = "model"
+	MetaLatency          = "latency"
+	MetaFinishReason     = "finish_reason"
+	MetaPromptTokens     = "prompt_tokens"
+	MetaCompletionTokens = "completion_tokens"
+	MetaTotalTokens      = "total_tokens"
+	MetaRating           = "rating"
+	MetaRatingNote       = "rating_note"
+)
+
+// Values of the rating metadata
+const (
+	RatingGood = "good"
+	RatingBad  = "bad"
+)
+
+// SessionStorage stores the chat sessions. The optional interfaces below
+// add features, such as a trash or forking, that the chat checks for.
 type SessionStorage interface {
 	NewSession() (ChatSession, error)
 	ListSessions() []ChatSession
@@ -66,11 +94,80 @@ type SessionStorage interface {
 	DeleteSession(string) error
 }
 
+// SummaryStorage is implemented by storages that list sessions in pages
+// without loading their messages. Other storages are listed with
+// ListSessions.
+type SummaryStorage interface {
+	ListSessionSummaries(offset int, limit int) ([]SessionSummary, error)
+}
+
+// MetadataStorage is implemented by storages that update the fields of a
+// session without saving its messages again. Other storages get the session
+// loaded and saved with the change.
+type MetadataStorage interface {
+	RenameSession(id string, title string) error
+	SetSessionProvider(id string, provider string, model string) error
+	SetSessionTags(id string, tags []string) error
+	PinSession(id string, pinned bool) error
+}
+
+// TrashStorage is implemented by storages that keep deleted sessions in a
+// trash, they can be restored until they are purged.
+type TrashStorage interface {
+	ListTrash() []ChatSession
+	RestoreSession(string) error
+	PurgeSession(string) error
+	PurgeTrash(olderThan time.Duration) (int, error)
+}
+
+// ForkableStorage is implemented by storages that copy a session up to a
+// message into a new session linked to it.
+type ForkableStorage interface {
+	ForkSession(id string, uptoMsg int) (ChatSession, error)
+}
+
+// EditableStorage is implemented by storages that change single messages
+// by their ID, used for ratings and deleting messages.
+type EditableStorage interface {
+	AnnotateMsg(sessionID string, msgID string, meta MsgMeta) error
+	DeleteMsg(sessionID string, msgID string) error
+}
+
 type ChatSession struct {
 	ID        string
+	ParentID  string
 	Title     string
+	Provider  string
+	Model     string
+	Tags      []string
+	Pinned    bool
 	Msgs      []Msg
 	CreatedAt int64
+	UpdatedAt int64
+	DeletedAt int64
+}
+
+// LockableStorage is implemented by storages that encrypt their content and
+// must be unlocked with a passphrase before sessions can be loaded.
+type LockableStorage interface {
+	Locked() bool
+	Unlock(passphrase string) error
+	Rekey(passphrase string) error
+}
+
+// SessionSummary describes a session for listings without its messages.
+type SessionSummary struct {
+	ID          string
+	ParentID    string
+	ParentTitle string
+	Title       string
+	Provider    string
+	Tags        []string
+	Pinned      bool
+	MsgCount    int
+	Snippet     string
+	CreatedAt   int64
+	UpdatedAt   int64
 }
 
 type ChatProvider interface {
@@ -81,6 +178,38 @@ type ChatProvider interface {
 	Stream(ctx context.Context, callback func(ctx context.Context, msg Msg) error)
 }
 
+// ModelProvider is implemented by providers backed by a specific model, the
+// model is recorded on the sessions the provider is used with.
+type ModelProvider interface {
+	Model() string
+}
+
+// DebugProvider is implemented by providers that report raw events, such
+// as request payloads and stream chunks, for the debug panel.
+type DebugProvider interface {
+	Debug(callback func(event DebugEvent))
+}
+
+// Kinds of debug events
+const (
+	DebugRequest  = "request"
+	DebugChunk    = "chunk"
+	DebugResponse = "response"
+	DebugError    = "error"
+)
+
+type DebugEvent struct {
+	Time     time.Time
+	Provider string
+	Kind     string
+	Text     string
+}
+
+// SessionTitler generates a short title summarizing a chat session.
+type SessionTitler interface {
+	GenerateTitle(ctx context.Context, session ChatSession) (string, error)
+}
+
 type ProviderType int
 
 const (
@@ -101,3 +230,72 @@ func (t ProviderType) String() string {
 		return fmt.Sprintf("ProviderType(%d)", t)
 	}
 }
+
+// RetentionPolicy limits how much chat history is kept. Zero values disable
+// the respective limit.
+type RetentionPolicy struct {
+	MaxAge        time.Duration
+	MaxSessions   int
+	MaxTotalSize  int64
+	ExcludePinned bool
+
+	// Interval between enforcements while clipt is running, defaults to an
+	// hour.
+	Interval time.Duration
+}
+
+func (policy RetentionPolicy) Enabled() bool {
+	return policy.MaxAge > 0 || policy.MaxSessions > 0 || policy.MaxTotalSize > 0
+}
+
+// RetentionReport lists the sessions removed, or that would be removed on a
+// dry run, by a retention policy.
+type RetentionReport struct {
+	DryRun  bool
+	Removed []SessionSummary
+	Bytes   int64
+}
+
+// RetentionStorage is implemented by storages that can enforce retention
+// policies.
+type RetentionStorage interface {
+	ApplyRetention(policy RetentionPolicy, dryRun bool) (RetentionReport, error)
+}
+
+type ChangeKind int
+
+const (
+	SessionCreated ChangeKind = iota
+	SessionUpdated
+	SessionDeleted
+	MsgAppended
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case SessionCreated:
+		return "SessionCreated"
+	case SessionUpdated:
+		return "SessionUpdated"
+	case SessionDeleted:
+		return "SessionDeleted"
+	case MsgAppended:
+		return "MsgAppended"
+	default:
+		return fmt.Sprintf("ChangeKind(%d)", k)
+	}
+}
+
+// ChangeEvent describes a change to a stored session. External is set for
+// changes written by another process sharing the same storage.
+type ChangeEvent struct {
+	Kind      ChangeKind
+	SessionID string
+	External  bool
+}
+
+// WatchableStorage is implemented by storages that publish change events.
+// The returned function cancels the subscription.
+type WatchableStorage interface {
+	Subscribe() (<-chan ChangeEvent, func())
+}
@@ -1,6 +1,10 @@
 package schema
 
-import "github.com/charmbracelet/bubbles/list"
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+)
 
 type Mode int
 
@@ -10,11 +14,55 @@ const (
 	Action
 )
 
+func (m Mode) String() string {
+	switch m {
+	case Chat:
+		return "CHAT"
+	case Debug:
+		return "DEBUG"
+	case Action:
+		return "ACTION"
+	default:
+		return "CHAT"
+	}
+}
+
 type Config struct {
 	Providers []ChatProvider
 	Style     LayoutStyle
 	Storage   SessionStorage
 	Cmds      []list.Item
+	AutoTitle SessionTitler
+
+	// TrashRetention is how long deleted sessions are kept in the trash
+	// before they are purged on startup, zero keeps them forever.
+	TrashRetention time.Duration
+
+	// Retention is enforced on startup and periodically while running.
+	Retention RetentionPolicy
+
+	// TemplateDir is the directory prompt templates are loaded from.
+	TemplateDir string
+
+	// Input keys for sending and inserting a newline, the number of rows
+	// the input grows to before it scrolls, and whether prompts written in
+	// the external editor are sent right away.
+	Input struct {
+		SendKeys    []string
+		NewlineKeys []string
+		MaxHeight   int
+		EditorSend  bool
+	}
+
+	// History of sent prompts, kept at Size entries and saved to Path when
+	// it's set.
+	History struct {
+		Path string
+		Size int
+	}
+
+	// Sidebar opens the sessions sidebar on start, ctrl+b toggles it.
+	Sidebar bool
 
 	Debug struct {
 		Log  bool
@@ -0,0 +1,89 @@
+package schema
+
+import "github.com/charmbracelet/bubbles/list"
+
+// CmdContext is what commands added with clipt.WithAddedCmds act on the
+// chat through, without depending on the layout. The changes are applied
+// once the command returns.
+type CmdContext interface {
+	// Session is the current session with the messages shown in the chat.
+	Session() ChatSession
+	Provider() ChatProvider
+	Providers() []ChatProvider
+
+	// Args are the arguments typed after the command name.
+	Args() Args
+
+	// Send sends the prompt to the current provider like typed in the input.
+	Send(prompt string)
+
+	// AddMsg appends a message to the transcript without sending it, use
+	// InternalMsg for notes and ErrMsg for errors.
+	AddMsg(role MsgRole, content string)
+
+	// SetInput replaces the text of the input.
+	SetInput(text string)
+
+	SwitchProvider(name string) error
+
+	// OpenMenu opens a submenu with the items, either CmdItem or ContextCmd.
+	OpenMenu(items []list.Item)
+
+	// Prompt asks for a line of text in place of the input, done isn't
+	// called when it's cancelled.
+	Prompt(label string, done func(ctx CmdContext, value string))
+
+	// Confirm asks the question in a yes or no submenu.
+	Confirm(question string, done func(ctx CmdContext))
+
+	// Go runs the work in the background, the function it returns is run
+	// with a new context once it's done.
+	Go(work func() func(ctx CmdContext))
+}
+
+// ContextCmd is a command run with a CmdContext. It can name its arguments
+// with a Usage() string method, like an ArgsCmd, and suggest values for
+// them as a ContextCompleter.
+type ContextCmd interface {
+	list.Item
+
+	Title() string
+	Description() string
+	Run(ctx CmdContext)
+}
+
+// ContextCompleter is implemented by context commands suggesting values for
+// the argument being typed.
+type ContextCompleter interface {
+	Complete(ctx CmdContext, args Args) []string
+}
+
+// Cmd is a ContextCmd running the action, e.g.
+//
+//	schema.Cmd{Name: "/hello", Desc: "Say hello", Action: func(ctx schema.CmdContext) {
+//		ctx.AddMsg(schema.InternalMsg, "Hello "+ctx.Args().Raw)
+//	}}
+type Cmd struct {
+	Name   string
+	Desc   string
+	Args   string
+	Action func(ctx CmdContext)
+}
+
+func (cmd Cmd) Title() string       { return cmd.Name }
+func (cmd Cmd) Description() string { return cmd.Desc }
+func (cmd Cmd) FilterValue() string { return cmd.Name }
+func (cmd Cmd) Run(ctx CmdContext) {
+	if cmd.Action != nil {
+		cmd.Action(ctx)
+	}
+}
+
+// Usage is the name followed by the arguments, empty without arguments.
+func (cmd Cmd) Usage() string {
+	if cmd.Args == "" {
+		return ""
+	}
+
+	return cmd.Name + " " + cmd.Args
+}
@@ -12,3 +12,21 @@ type CmdItem interface {
 	Description() string
 	Execute(tea.Model) (tea.Model, tea.Cmd)
 }
+
+// ArgsCmd is a command taking arguments after its name. It's run with the
+// arguments parsed from the prompt, and its usage is shown while they are
+// typed or when required ones are missing.
+type ArgsCmd interface {
+	CmdItem
+
+	// Usage names the arguments after the command, <arg> is required and
+	// [arg] optional, e.g. "/rename <title>" or "/dataset [path]".
+	Usage() string
+	ExecuteArgs(model tea.Model, args Args) (tea.Model, tea.Cmd)
+}
+
+// Completer is implemented by commands suggesting values for the argument
+// being typed, the menu lists the ones matching args.Partial.
+type Completer interface {
+	Complete(model tea.Model, args Args) []string
+}
@@ -24,6 +24,7 @@ type LayoutStyle struct {
 		ItemNormal   lipgloss.Style
 		ItemSelected lipgloss.Style
 		Description  lipgloss.Style
+		Match        lipgloss.Style
 	}
 
 	Chat struct {
@@ -31,13 +32,33 @@ type LayoutStyle struct {
 		Header      lipgloss.Style
 		Input       lipgloss.Style
 
+		// Match highlights the results of a search in the transcript
+		Match        lipgloss.Style
+		CurrentMatch lipgloss.Style
+
 		Msg struct {
 			User     lipgloss.Style
 			AI       lipgloss.Style
 			Sys      lipgloss.Style
 			Err      lipgloss.Style
 			Internal lipgloss.Style
+			Selected lipgloss.Style
 			Glamour  ansi.StyleConfig
 		}
 	}
+
+	Sidebar struct {
+		ContentView lipgloss.Style
+		Title       lipgloss.Style
+		Group       lipgloss.Style
+		Item        lipgloss.Style
+		Active      lipgloss.Style
+		Selected    lipgloss.Style
+	}
+
+	Debug struct {
+		Section lipgloss.Style
+		Label   lipgloss.Style
+		Text    lipgloss.Style
+	}
 }
@@ -0,0 +1,400 @@
+package tui
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/struki84/clipt/tui/chat"
+	"github.com/struki84/clipt/tui/menu"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+const (
+	// SidebarWidth is the width the sidebar takes from the chat
+	SidebarWidth = 32
+
+	// sidebarMinWidth is the narrowest terminal the sidebar is shown in
+	sidebarMinWidth = 100
+
+	// sidebarLimit is the number of recent sessions listed
+	sidebarLimit = 200
+)
+
+// Sidebar lists the recent sessions next to the chat. Cursor is the ID of
+// the session the keys move from, Offset the first row shown.
+type Sidebar struct {
+	Open     bool
+	Focused  bool
+	Sessions []schema.SessionSummary
+	Cursor   string
+	Offset   int
+	Running  map[string]bool
+}
+
+// SidebarMsg carries the sessions loaded for the sidebar.
+type SidebarMsg struct {
+	Sessions []schema.SessionSummary
+	Err      error
+}
+
+// sidebarRow is a line of the sidebar, a group label or a session.
+type sidebarRow struct {
+	group   string
+	session int
+}
+
+func NewSidebar(open bool) Sidebar {
+	return Sidebar{
+		Open:     open,
+		Sessions: []schema.SessionSummary{},
+		Running:  map[string]bool{},
+	}
+}
+
+// SessionGroup names the group the session is listed in by when it was
+// last updated.
+func SessionGroup(session schema.SessionSummary, now time.Time) string {
+	if session.Pinned {
+		return "Pinned"
+	}
+
+	updated := time.Unix(session.UpdatedAt, 0)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch {
+	case !updated.Before(today):
+		return "Today"
+	case !updated.Before(today.AddDate(0, 0, -1)):
+		return "Yesterday"
+	case !updated.Before(today.AddDate(0, 0, -7)):
+		return "Last week"
+	default:
+		return "Older"
+	}
+}
+
+func (sidebar Sidebar) rows(now time.Time) []sidebarRow {
+	rows := []sidebarRow{}
+	group := ""
+
+	for i, session := range sidebar.Sessions {
+		if next := SessionGroup(session, now); next != group {
+			if group != "" {
+				rows = append(rows, sidebarRow{session: -1})
+			}
+
+			group = next
+			rows = append(rows, sidebarRow{group: group, session: -1})
+		}
+
+		rows = append(rows, sidebarRow{session: i})
+	}
+
+	return rows
+}
+
+func (sidebar Sidebar) cursorIndex() int {
+	for i, session := range sidebar.Sessions {
+		if session.ID == sidebar.Cursor {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Move moves the cursor by step sessions and scrolls it into the height.
+func (sidebar Sidebar) Move(step int, height int) Sidebar {
+	if len(sidebar.Sessions) == 0 {
+		return sidebar
+	}
+
+	index := min(max(sidebar.cursorIndex()+step, 0), len(sidebar.Sessions)-1)
+	sidebar.Cursor = sidebar.Sessions[index].ID
+
+	return sidebar.scroll(height)
+}
+
+// scroll keeps the row of the cursor between the first and last row shown.
+func (sidebar Sidebar) scroll(height int) Sidebar {
+	index := sidebar.cursorIndex()
+
+	for row, line := range sidebar.rows(time.Now()) {
+		if line.session != index || index < 0 {
+			continue
+		}
+
+		// Show the label of the first group with its first session
+		if row <= 1 {
+			row = 0
+		}
+
+		if row < sidebar.Offset {
+			sidebar.Offset = row
+		} else if row >= sidebar.Offset+height {
+			sidebar.Offset = row - height + 1
+		}
+	}
+
+	return sidebar
+}
+
+func (sidebar Sidebar) View(width int, height int, activeID string, loader string, style schema.LayoutStyle) string {
+	itemWidth := width - style.Sidebar.ContentView.GetHorizontalFrameSize()
+	lines := []string{style.Sidebar.Title.Width(itemWidth).Render("Sessions")}
+
+	if len(sidebar.Sessions) == 0 {
+		lines = append(lines, style.Sidebar.Group.Width(itemWidth).Render("No saved sessions"))
+	}
+
+	rows := sidebar.rows(time.Now())
+	for _, row := range rows[min(sidebar.Offset, len(rows)):] {
+		if len(lines) >= height {
+			break
+		}
+
+		if row.session < 0 {
+			lines = append(lines, style.Sidebar.Group.Width(itemWidth).Render(row.group))
+			continue
+		}
+
+		session := sidebar.Sessions[row.session]
+
+		marker := "  "
+		if sidebar.Running[session.ID] {
+			marker = loader
+		}
+
+		title := truncate(session.Title, itemWidth-lipgloss.Width(marker)-1)
+
+		item := style.Sidebar.Item
+		if session.ID == activeID {
+			item = style.Sidebar.Active
+		}
+
+		if sidebar.Focused && session.ID == sidebar.Cursor {
+			item = style.Sidebar.Selected
+		}
+
+		lines = append(lines, item.Width(itemWidth).Render(title+" "+marker))
+	}
+
+	return style.Sidebar.ContentView.
+		Width(width - style.Sidebar.ContentView.GetHorizontalBorderSize()).
+		Height(height).
+		Render(strings.Join(lines, "\n"))
+}
+
+// truncate shortens the text to the width, ending it with an ellipsis.
+func truncate(text string, width int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if lipgloss.Width(string(runes)) <= width {
+		return string(runes)
+	}
+
+	for len(runes) > 0 && lipgloss.Width(string(runes))+1 > width {
+		runes = runes[:len(runes)-1]
+	}
+
+	return string(runes) + "…"
+}
+
+// SidebarVisible reports whether the sidebar is open and the terminal is
+// wide enough to show it.
+func (layout LayoutView) SidebarVisible() bool {
+	return layout.Sidebar.Open && layout.WindowSize.Width >= sidebarMinWidth
+}
+
+// OverSidebar reports whether the cell of the screen is in the sidebar.
+func (layout LayoutView) OverSidebar(x int, y int) bool {
+	left := layout.Style.ContentView.GetMarginLeft() + layout.Style.ContentView.GetBorderLeftSize() + layout.Style.ContentView.GetPaddingLeft()
+	return layout.SidebarVisible() && x >= left && x < left+SidebarWidth && y >= 0 && y < layout.sidebarTop()+layout.SidebarHeight()
+}
+
+// sidebarTop is the line of the screen the sidebar title is on.
+func (layout LayoutView) sidebarTop() int {
+	top := 0
+	for _, style := range []lipgloss.Style{layout.Style.ContentView, layout.Style.Sidebar.ContentView} {
+		top += style.GetMarginTop() + style.GetBorderTopSize() + style.GetPaddingTop()
+	}
+
+	return top
+}
+
+// ChatWidth is the width left for the chat next to the sidebar.
+func (layout LayoutView) ChatWidth() int {
+	if layout.SidebarVisible() {
+		return layout.WindowSize.Width - SidebarWidth
+	}
+
+	return layout.WindowSize.Width
+}
+
+// SidebarHeight is the height of the sidebar, the info and status lines
+// span the whole width below it.
+func (layout LayoutView) SidebarHeight() int {
+	return layout.WindowSize.Height - lipgloss.Height(layout.Style.InfoLine.Render("")) - 1
+}
+
+// Resize fits the chat and the menu into the width left by the sidebar.
+func (layout LayoutView) Resize() LayoutView {
+	size := tea.WindowSizeMsg{Width: layout.ChatWidth(), Height: layout.WindowSize.Height}
+
+	chatModel, _ := layout.Chat.Update(size)
+	layout.Chat = chatModel.(chat.ChatView)
+
+	menuModel, _ := layout.Menu.Update(size)
+	layout.Menu = menuModel.(menu.ChatMenu)
+
+	return layout
+}
+
+// LoadSidebar lists the recent sessions in the background while the
+// sidebar is open.
+func (layout LayoutView) LoadSidebar() tea.Cmd {
+	if !layout.Sidebar.Open || layout.Storage == nil {
+		return nil
+	}
+
+	storage := summaryStorage(layout.Storage)
+
+	return func() tea.Msg {
+		sessions, err := storage.ListSessionSummaries(0, sidebarLimit)
+		return SidebarMsg{Sessions: sessions, Err: err}
+	}
+}
+
+func (layout LayoutView) HandleSidebar(msg SidebarMsg) LayoutView {
+	if msg.Err != nil {
+		log.Printf("Error while listing sessions: %s", msg.Err)
+		return layout
+	}
+
+	layout.Sidebar.Sessions = msg.Sessions
+	if layout.Sidebar.cursorIndex() < 0 {
+		layout.Sidebar.Cursor = layout.Chat.Session.ID
+	}
+
+	if layout.Sidebar.cursorIndex() < 0 && len(msg.Sessions) > 0 {
+		layout.Sidebar.Cursor = msg.Sessions[0].ID
+	}
+
+	layout.Sidebar = layout.Sidebar.scroll(layout.SidebarHeight() - 1)
+
+	return layout
+}
+
+// ToggleSidebar opens the sidebar with the focus on it, moves the focus
+// to an open sidebar, and closes a focused one.
+func (layout LayoutView) ToggleSidebar() (LayoutView, tea.Cmd) {
+	switch {
+	case !layout.Sidebar.Open:
+		layout.Sidebar.Open = true
+		layout.Sidebar.Cursor = layout.Chat.Session.ID
+		layout = layout.FocusSidebar(true)
+	case !layout.Sidebar.Focused:
+		layout = layout.FocusSidebar(true)
+	default:
+		layout.Sidebar.Open = false
+		layout = layout.FocusSidebar(false)
+	}
+
+	if layout.Sidebar.Open && !layout.SidebarVisible() {
+		layout = layout.FocusSidebar(false)
+		layout = layout.AddMsg(schema.InternalMsg, "The terminal is too narrow to show the sidebar")
+	}
+
+	return layout.Resize(), layout.LoadSidebar()
+}
+
+// FocusSidebar moves the keys to the sidebar, or back to the input.
+func (layout LayoutView) FocusSidebar(focused bool) LayoutView {
+	layout.Sidebar.Focused = focused
+
+	if focused {
+		layout.Chat.Input.Blur()
+	} else if layout.Mode != schema.Action {
+		layout.Chat.Input.Focus()
+	}
+
+	layout.Info = layout.ChatInfo()
+
+	return layout
+}
+
+// UpdateSidebar handles the keys while the sidebar has the focus.
+func (layout LayoutView) UpdateSidebar(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	height := layout.SidebarHeight() - 1
+
+	switch msg.String() {
+	case "ctrl+b":
+		return layout.ToggleSidebar()
+	case "esc", "tab":
+		return layout.FocusSidebar(false), nil
+	case "j", "down":
+		layout.Sidebar = layout.Sidebar.Move(1, height)
+	case "k", "up":
+		layout.Sidebar = layout.Sidebar.Move(-1, height)
+	case "pgdown":
+		layout.Sidebar = layout.Sidebar.Move(height, height)
+	case "pgup":
+		layout.Sidebar = layout.Sidebar.Move(-height, height)
+	case "g", "home":
+		layout.Sidebar = layout.Sidebar.Move(-len(layout.Sidebar.Sessions), height)
+	case "G", "end":
+		layout.Sidebar = layout.Sidebar.Move(len(layout.Sidebar.Sessions), height)
+	case "enter":
+		layout = layout.OpenSidebarSession(layout.Sidebar.Cursor)
+		return layout.FocusSidebar(false), nil
+	}
+
+	return layout, nil
+}
+
+// UpdateSidebarMouse scrolls the sidebar with the wheel and opens the
+// clicked session.
+func (layout LayoutView) UpdateSidebarMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+	rows := layout.Sidebar.rows(time.Now())
+	height := layout.SidebarHeight() - 1
+
+	if !layout.OverSidebar(msg.X, msg.Y) {
+		return layout, nil
+	}
+
+	switch msg.Button {
+	case tea.MouseButtonWheelUp:
+		layout.Sidebar.Offset = max(layout.Sidebar.Offset-3, 0)
+	case tea.MouseButtonWheelDown:
+		layout.Sidebar.Offset = max(min(layout.Sidebar.Offset+3, len(rows)-height), 0)
+	case tea.MouseButtonLeft:
+		// The first line is the title
+		line := msg.Y - layout.sidebarTop() - 1
+		row := layout.Sidebar.Offset + line
+		if msg.Action != tea.MouseActionPress || line < 0 || row >= len(rows) || rows[row].session < 0 {
+			break
+		}
+
+		session := layout.Sidebar.Sessions[rows[row].session]
+		layout.Sidebar.Cursor = session.ID
+		layout = layout.OpenSidebarSession(session.ID)
+	}
+
+	return layout, nil
+}
+
+// OpenSidebarSession loads the session and opens it in the chat.
+func (layout LayoutView) OpenSidebarSession(id string) LayoutView {
+	if id == "" || id == layout.Chat.Session.ID {
+		return layout
+	}
+
+	session, err := layout.Storage.LoadSession(id)
+	if err != nil {
+		return layout.AddMsg(schema.ErrMsg, err.Error())
+	}
+
+	return layout.OpenSession(session)
+}
@@ -0,0 +1,127 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+func TestSessionGroup(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	midnight := time.Date(2026, 10, 19, 0, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		updated time.Time
+		pinned  bool
+		group   string
+	}{
+		{now, false, "Today"},
+		{midnight, false, "Today"},
+		{midnight.Add(-time.Second), false, "Yesterday"},
+		{midnight.AddDate(0, 0, -1), false, "Yesterday"},
+		{midnight.AddDate(0, 0, -1).Add(-time.Second), false, "Last week"},
+		{midnight.AddDate(0, 0, -7), false, "Last week"},
+		{midnight.AddDate(0, 0, -7).Add(-time.Second), false, "Older"},
+		{midnight.AddDate(-1, 0, 0), true, "Pinned"},
+	}
+
+	for _, test := range tests {
+		session := schema.SessionSummary{UpdatedAt: test.updated.Unix(), Pinned: test.pinned}
+		if group := SessionGroup(session, now); group != test.group {
+			t.Errorf("Expected %s updated at %s in %s, got %s", test.updated, now, test.group, group)
+		}
+	}
+}
+
+// testSidebar lists two sessions of today and two older ones, the rows are
+// the group label, s0, s1, a spacer, the group label, s2 and s3.
+func testSidebar() Sidebar {
+	now := time.Now()
+	sidebar := NewSidebar(true)
+	sidebar.Sessions = []schema.SessionSummary{
+		{ID: "s0", UpdatedAt: now.Unix()},
+		{ID: "s1", UpdatedAt: now.Unix()},
+		{ID: "s2", UpdatedAt: now.AddDate(0, 0, -30).Unix()},
+		{ID: "s3", UpdatedAt: now.AddDate(0, 0, -31).Unix()},
+	}
+	sidebar.Cursor = "s0"
+
+	return sidebar
+}
+
+func TestSidebarMove(t *testing.T) {
+	sidebar := testSidebar()
+
+	tests := []struct {
+		step   int
+		cursor string
+		offset int
+	}{
+		{1, "s1", 0},
+		{1, "s2", 3},
+		{-1, "s1", 2},
+		{-5, "s0", 0},
+		{10, "s3", 4},
+		{1, "s3", 4},
+	}
+
+	for i, test := range tests {
+		sidebar = sidebar.Move(test.step, 3)
+		if sidebar.Cursor != test.cursor || sidebar.Offset != test.offset {
+			t.Errorf("Step %d: expected cursor %s at offset %d, got %s at %d", i, test.cursor, test.offset, sidebar.Cursor, sidebar.Offset)
+		}
+	}
+}
+
+func TestSidebarMouse(t *testing.T) {
+	layout, _ := newTestLayout(t)
+
+	ids := []string{}
+	for range 4 {
+		session, err := layout.Storage.NewSession()
+		if err != nil {
+			t.Fatalf("Failed to create session: %v", err)
+		}
+
+		ids = append(ids, session.ID)
+	}
+
+	layout.WindowSize = tea.WindowSizeMsg{Width: 120, Height: 30}
+	layout.Sidebar = testSidebar()
+	for i := range layout.Sidebar.Sessions {
+		layout.Sidebar.Sessions[i].ID = ids[i]
+	}
+
+	click := func(layout LayoutView, x int, y int) LayoutView {
+		model, _ := layout.UpdateSidebarMouse(tea.MouseMsg{X: x, Y: y, Button: tea.MouseButtonLeft, Action: tea.MouseActionPress})
+		return model.(LayoutView)
+	}
+
+	// The title, the group label and the chat next to the sidebar
+	for _, cell := range [][2]int{{2, 0}, {2, 1}, {SidebarWidth + 2, 2}} {
+		if clicked := click(layout, cell[0], cell[1]); clicked.Chat.Session.ID == ids[0] || clicked.Chat.Session.ID == ids[1] {
+			t.Errorf("Expected no session opened by a click at %v", cell)
+		}
+	}
+
+	if clicked := click(layout, 2, 2); clicked.Chat.Session.ID != ids[0] {
+		t.Errorf("Expected the first session opened, got %s", clicked.Chat.Session.ID)
+	}
+
+	layout.Sidebar.Offset = 3
+	if clicked := click(layout, 2, 4); clicked.Chat.Session.ID != ids[3] {
+		t.Errorf("Expected the last session opened when scrolled, got %s", clicked.Chat.Session.ID)
+	}
+
+	// A frame above the sidebar moves its rows down
+	layout.Style.Sidebar.ContentView = layout.Style.Sidebar.ContentView.PaddingTop(1)
+	layout.Sidebar.Offset = 0
+	if clicked := click(layout, 2, 2); clicked.Chat.Session.ID == ids[0] {
+		t.Error("Expected the group label under the frame, got the first session")
+	}
+	if clicked := click(layout, 2, 3); clicked.Chat.Session.ID != ids[0] {
+		t.Errorf("Expected the first session below the frame, got %s", clicked.Chat.Session.ID)
+	}
+}
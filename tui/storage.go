@@ -0,0 +1,130 @@
+package tui
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// summaryStorage returns the storage as a SummaryStorage, other storages
+// get their summaries made from ListSessions.
+func summaryStorage(storage schema.SessionStorage) schema.SummaryStorage {
+	if summaries, ok := storage.(schema.SummaryStorage); ok {
+		return summaries
+	}
+
+	return listedSummaries{storage}
+}
+
+type listedSummaries struct {
+	storage schema.SessionStorage
+}
+
+// ListSessionSummaries lists a page of sessions, pinned first and then by
+// most recent activity like the SQLite storage.
+func (listed listedSummaries) ListSessionSummaries(offset int, limit int) ([]schema.SessionSummary, error) {
+	sessions := listed.storage.ListSessions()
+
+	titles := map[string]string{}
+	for _, session := range sessions {
+		titles[session.ID] = session.Title
+	}
+
+	summaries := []schema.SessionSummary{}
+	for _, session := range sessions {
+		summary := schema.SessionSummary{
+			ID:          session.ID,
+			ParentID:    session.ParentID,
+			ParentTitle: titles[session.ParentID],
+			Title:       session.Title,
+			Provider:    session.Provider,
+			Tags:        append([]string{}, session.Tags...),
+			Pinned:      session.Pinned,
+			MsgCount:    len(session.Msgs),
+			CreatedAt:   session.CreatedAt,
+			UpdatedAt:   session.UpdatedAt,
+		}
+
+		if len(session.Msgs) > 0 {
+			summary.Snippet = snippet(session.Msgs[len(session.Msgs)-1].Content, 200)
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	slices.SortStableFunc(summaries, func(a, b schema.SessionSummary) int {
+		if a.Pinned != b.Pinned {
+			if a.Pinned {
+				return -1
+			}
+
+			return 1
+		}
+
+		return cmp.Compare(lastUsed(b), lastUsed(a))
+	})
+
+	start := min(max(offset, 0), len(summaries))
+	end := min(start+limit, len(summaries))
+
+	return summaries[start:end], nil
+}
+
+// metadataStorage returns the storage as a MetadataStorage, other storages
+// get the session loaded and saved again with the change.
+func metadataStorage(storage schema.SessionStorage) schema.MetadataStorage {
+	if metadata, ok := storage.(schema.MetadataStorage); ok {
+		return metadata
+	}
+
+	return savedMetadata{storage}
+}
+
+type savedMetadata struct {
+	storage schema.SessionStorage
+}
+
+func (saved savedMetadata) RenameSession(sessionID string, title string) error {
+	return saved.update(sessionID, func(session *schema.ChatSession) {
+		session.Title = title
+	})
+}
+
+func (saved savedMetadata) SetSessionProvider(sessionID string, provider string, model string) error {
+	return saved.update(sessionID, func(session *schema.ChatSession) {
+		session.Provider = provider
+		session.Model = model
+	})
+}
+
+func (saved savedMetadata) SetSessionTags(sessionID string, tags []string) error {
+	return saved.update(sessionID, func(session *schema.ChatSession) {
+		session.Tags = tags
+	})
+}
+
+func (saved savedMetadata) PinSession(sessionID string, pinned bool) error {
+	return saved.update(sessionID, func(session *schema.ChatSession) {
+		session.Pinned = pinned
+	})
+}
+
+// update saves the session with the change, sessions that can't be loaded
+// aren't stored yet and have nothing to update.
+func (saved savedMetadata) update(sessionID string, change func(session *schema.ChatSession)) error {
+	session, err := saved.storage.LoadSession(sessionID)
+	if err != nil || session.ID != sessionID {
+		return nil
+	}
+
+	change(&session)
+
+	_, err = saved.storage.SaveSession(session)
+	if err != nil {
+		return fmt.Errorf("Error updating session: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// plainStorage hides the optional interfaces of the storage it wraps.
+type plainStorage struct {
+	schema.SessionStorage
+}
+
+func TestOptionalStorage(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+
+	var storage schema.SessionStorage = sqliteDB
+	_, summaries := storage.(schema.SummaryStorage)
+	_, metadata := storage.(schema.MetadataStorage)
+	_, trash := storage.(schema.TrashStorage)
+	_, fork := storage.(schema.ForkableStorage)
+	_, editable := storage.(schema.EditableStorage)
+
+	if !summaries || !metadata || !trash || !fork || !editable {
+		t.Fatalf("Expected SQLite to implement the optional storage interfaces")
+	}
+
+	layout.Storage = plainStorage{sqliteDB}
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	model, _ := TrashCmd{title: "/trash"}.Execute(layout)
+	if lastMsg(model.(LayoutView)).Content != "The storage doesn't keep a trash" {
+		t.Fatalf("Expected /trash to report the missing trash")
+	}
+
+	model, _ = ForkCmd{title: "/fork"}.Execute(layout)
+	if lastMsg(model.(LayoutView)).Content != "The storage doesn't support forking sessions" {
+		t.Fatalf("Expected /fork to report the missing support")
+	}
+
+	layout = layout.EnterAction()
+	layout.Chat = layout.Chat.Select(0)
+	layout = pressAction(t, layout, "d")
+
+	if len(layout.Chat.Msgs) != 5 || layout.Chat.Msgs[0].Content != "first question" {
+		t.Fatalf("Expected the message to be kept, got %v", layout.Chat.Msgs)
+	}
+
+	if lastMsg(layout).Content != "The storage doesn't support deleting messages" {
+		t.Fatalf("Expected the delete to report the missing support")
+	}
+}
+
+func TestSavedMetadata(t *testing.T) {
+	layout, sqliteDB := newTestLayout(t)
+	layout.Storage = plainStorage{sqliteDB}
+	layout = openTestSession(t, layout, testMsgs(), nil)
+
+	layout = layout.RenameSession(layout.Chat.Session.ID, "Renamed")
+	model, _ := PinCmd{title: "/pin"}.Execute(layout)
+	layout = model.(LayoutView)
+	model, _ = TagCmd{title: "/tag"}.ExecuteArgs(layout, schema.ParseArgs("/tag go"))
+	layout = model.(LayoutView)
+
+	session, err := sqliteDB.LoadSession(layout.Chat.Session.ID)
+	if err != nil {
+		t.Fatalf("Failed to load session: %v", err)
+	}
+
+	if session.Title != "Renamed" || !session.Pinned || !slices.Equal(session.Tags, []string{"go"}) {
+		t.Fatalf("Expected the changes to be saved with the session, got %+v", session)
+	}
+
+	if len(session.Msgs) != 4 {
+		t.Fatalf("Expected the messages to be kept, got %d", len(session.Msgs))
+	}
+
+	// Sessions that aren't stored yet are left alone
+	count := len(sqliteDB.ListSessions())
+	err = metadataStorage(plainStorage{sqliteDB}).RenameSession("missing", "Title")
+	if err != nil || len(sqliteDB.ListSessions()) != count {
+		t.Fatalf("Expected nothing saved for a missing session, got %v", err)
+	}
+}
+
+func TestListedSummaries(t *testing.T) {
+	_, sqliteDB := newTestLayout(t)
+
+	// Drop the session the layout started with
+	for _, session := range sqliteDB.ListSessions() {
+		sqliteDB.DeleteSession(session.ID)
+	}
+
+	sessions := []schema.ChatSession{
+		{ID: "old", Title: "Old", CreatedAt: 100, Msgs: testMsgs()},
+		{ID: "new", Title: "New", CreatedAt: 300, ParentID: "old"},
+		{ID: "pinned", Title: "Pinned", CreatedAt: 200, Pinned: true},
+	}
+
+	for _, session := range sessions {
+		_, err := sqliteDB.SaveSession(session)
+		if err != nil {
+			t.Fatalf("Failed to save session: %v", err)
+		}
+	}
+
+	storage := summaryStorage(plainStorage{sqliteDB})
+	if _, ok := storage.(listedSummaries); !ok {
+		t.Fatalf("Expected the summaries to be listed from the sessions")
+	}
+
+	summaries, err := storage.ListSessionSummaries(0, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	titles := []string{}
+	for _, summary := range summaries {
+		titles = append(titles, summary.Title)
+	}
+
+	if !slices.Equal(titles, []string{"Pinned", "New", "Old"}) {
+		t.Fatalf("Expected pinned and then recent sessions first, got %q", titles)
+	}
+
+	if summaries[1].ParentTitle != "Old" || summaries[2].MsgCount != 4 || summaries[2].Snippet != "second answer" {
+		t.Fatalf("Unexpected summaries %+v", summaries)
+	}
+
+	page, _ := storage.ListSessionSummaries(2, 10)
+	if len(page) != 1 || page[0].ID != "old" {
+		t.Fatalf("Expected the last page to hold the oldest session, got %+v", page)
+	}
+
+	page, _ = storage.ListSessionSummaries(5, 10)
+	if len(page) != 0 {
+		t.Fatalf("Expected an empty page past the end, got %+v", page)
+	}
+}
@@ -217,6 +217,10 @@ func Default(scheme ColorScheme) (style schema.LayoutStyle) {
 		Padding(0).
 		Width(30)
 
+	style.Menu.Match = lipgloss.NewStyle().
+		Foreground(lipgloss.Color(chatMsgInternalBorderFGcolor)).
+		Bold(true)
+
 	style.Menu.Description = lipgloss.NewStyle().
 		Background(lipgloss.Color(secondaryBGcolor)).
 		Foreground(lipgloss.Color(menuDescFGcolor)).
@@ -304,6 +308,15 @@ func Default(scheme ColorScheme) (style schema.LayoutStyle) {
 		MarginBackground(lipgloss.Color(primaryBGcolor)).
 		Align(lipgloss.Left)
 
+	style.Chat.Msg.Selected = lipgloss.NewStyle().
+		BorderStyle(lipgloss.ThickBorder()).
+		BorderBackground(lipgloss.Color(primaryBGcolor)).
+		BorderForeground(lipgloss.Color(primaryFGcolor)).
+		BorderLeft(true).
+		BorderRight(false).
+		BorderTop(false).
+		BorderBottom(false)
+
 	style.Chat.Input = lipgloss.NewStyle().
 		Background(lipgloss.Color(secondaryBGcolor)).
 		Foreground(lipgloss.Color(secondaryFGcolor)).
@@ -316,5 +329,61 @@ func Default(scheme ColorScheme) (style schema.LayoutStyle) {
 		BorderBottom(false).
 		Padding(1, 1, 0, 1)
 
+	style.Sidebar.ContentView = lipgloss.NewStyle().
+		Background(lipgloss.Color(secondaryBGcolor)).
+		BorderStyle(lipgloss.ThickBorder()).
+		BorderBackground(lipgloss.Color(primaryBGcolor)).
+		BorderForeground(lipgloss.Color(tertiaryBGcolor)).
+		BorderRight(true).
+		BorderLeft(false).
+		BorderTop(false).
+		BorderBottom(false).
+		Padding(0, 1)
+
+	style.Sidebar.Title = lipgloss.NewStyle().
+		Background(lipgloss.Color(secondaryBGcolor)).
+		Foreground(lipgloss.Color(primaryFGcolor)).
+		Bold(true)
+
+	style.Sidebar.Group = lipgloss.NewStyle().
+		Background(lipgloss.Color(secondaryBGcolor)).
+		Foreground(lipgloss.Color(tertiaryFGcolor))
+
+	style.Sidebar.Item = lipgloss.NewStyle().
+		Background(lipgloss.Color(secondaryBGcolor)).
+		Foreground(lipgloss.Color(secondaryFGcolor))
+
+	style.Sidebar.Active = lipgloss.NewStyle().
+		Background(lipgloss.Color(secondaryBGcolor)).
+		Foreground(lipgloss.Color(primaryFGcolor)).
+		Bold(true)
+
+	style.Sidebar.Selected = lipgloss.NewStyle().
+		Background(lipgloss.Color(tertiaryBGcolor)).
+		Foreground(lipgloss.Color(secondaryFGcolor))
+
+	style.Chat.Match = lipgloss.NewStyle().
+		Background(lipgloss.Color(tertiaryFGcolor)).
+		Foreground(lipgloss.Color(primaryBGcolor))
+
+	style.Chat.CurrentMatch = lipgloss.NewStyle().
+		Background(lipgloss.Color(chatMsgInternalBorderFGcolor)).
+		Foreground(lipgloss.Color(primaryBGcolor)).
+		Bold(true)
+
+	style.Debug.Section = lipgloss.NewStyle().
+		Background(lipgloss.Color(primaryBGcolor)).
+		Foreground(lipgloss.Color(primaryFGcolor)).
+		Bold(true).
+		MarginTop(1)
+
+	style.Debug.Label = lipgloss.NewStyle().
+		Background(lipgloss.Color(primaryBGcolor)).
+		Foreground(lipgloss.Color(tertiaryFGcolor))
+
+	style.Debug.Text = lipgloss.NewStyle().
+		Background(lipgloss.Color(primaryBGcolor)).
+		Foreground(lipgloss.Color(secondaryFGcolor))
+
 	return style
 }
@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"log"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/struki84/clipt/tui/schema"
+)
+
+// ChangeMsg carries a change event published by the storage.
+type ChangeMsg struct {
+	Event schema.ChangeEvent
+}
+
+// Close cancels the subscription to storage changes, once the program has
+// quit.
+func (layout LayoutView) Close() {
+	if layout.unsubscribe != nil {
+		layout.unsubscribe()
+	}
+}
+
+// WaitForChange waits for the next storage change event.
+func (layout LayoutView) WaitForChange() tea.Cmd {
+	changes := layout.Changes
+	if changes == nil {
+		return nil
+	}
+
+	return func() tea.Msg {
+		event, ok := <-changes
+		if !ok {
+			return nil
+		}
+
+		return ChangeMsg{Event: event}
+	}
+}
+
+// HandleChange refreshes the open session and the sessions submenu when
+// another process changes the storage.
+func (layout LayoutView) HandleChange(msg ChangeMsg) (LayoutView, tea.Cmd) {
+	event := msg.Event
+	if !event.External || layout.Storage == nil || layout.Prompt.Active {
+		return layout, nil
+	}
+
+	cmds := []tea.Cmd{}
+
+	if event.SessionID == layout.Chat.Session.ID {
+		layout = layout.RefreshSession(event)
+	}
+
+	// The sessions list is reloaded from the first page, pages already
+	// loaded may have shifted
+	if layout.SessionPages.Active {
+		layout.Menu = layout.Menu.PushMenu([]list.Item{})
+		layout.SessionPages = SessionPages{Active: true, Loading: true}
+		cmds = append(cmds, layout.LoadSessionPage(0))
+	}
+
+	return layout, tea.Batch(cmds...)
+}
+
+// RefreshSession reloads the open session after it was changed by another
+// process. A running reply is left alone, it's saved over the session anyway.
+func (layout LayoutView) RefreshSession(event schema.ChangeEvent) LayoutView {
+	if layout.Chat.IsLoading {
+		return layout
+	}
+
+	if event.Kind == schema.SessionDeleted {
+		return layout.AddMsg(schema.InternalMsg, "This session was deleted in another window")
+	}
+
+	session, err := layout.Storage.LoadSession(event.SessionID)
+	if err != nil {
+		log.Printf("Error while refreshing session: %s", err)
+		return layout
+	}
+
+	if session.ID != event.SessionID {
+		return layout
+	}
+
+	atBottom := layout.Chat.Viewport.AtBottom()
+
+	layout.Chat.Session = session
+	layout.Chat.Msgs = session.Msgs
+	layout.Chat.Viewport.SetContent(layout.Chat.RenderMsgs())
+
+	if atBottom {
+		layout.Chat.Viewport.GotoBottom()
+	}
+
+	return layout
+}